@@ -12,9 +12,13 @@ import (
 	"time"
 
 	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/external"
+	"github.com/drae/templated-secret-controller/pkg/fileinput"
 	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/drae/templated-secret-controller/pkg/httppush"
 	"github.com/drae/templated-secret-controller/pkg/satoken"
 	"github.com/drae/templated-secret-controller/pkg/tracker"
+	"github.com/drae/templated-secret-controller/pkg/vault"
 
 	"github.com/go-logr/logr"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -51,6 +55,22 @@ var (
 	reconciliationInterval     = time.Hour
 	maxSecretAge               = 720 * time.Hour
 	logLevel                   = "info"
+	fileInputDirectory         = ""
+	vaultAddress               = ""
+	vaultAuthMount             = "kubernetes"
+	vaultCACert                = ""
+	vaultNamespace             = ""
+	externalProviderDirectory  = ""
+	externalProviderTimeout    = 10 * time.Second
+	httpPushURLPrefixes        = ""
+	pauseReconciliation        = false
+	enablePolicies             = false
+	enableEncryptedConfigMaps  = false
+	impersonateServiceAccounts = false
+	tokenExpiration            = time.Hour
+	tokenAudiences             = ""
+	issuedTokenMaxExpiration   = time.Hour
+	issuedTokenAudiences       = "templatedsecret.starstreak.dev/service-account-token"
 )
 
 func main() {
@@ -62,6 +82,22 @@ func main() {
 	flag.DurationVar(&reconciliationInterval, "reconciliation-interval", time.Hour, "How often to reconcile SecretTemplates")
 	flag.DurationVar(&maxSecretAge, "max-secret-age", 720*time.Hour, "Maximum age of a secret before forcing regeneration")
 	flag.StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+	flag.StringVar(&fileInputDirectory, "file-input-directory", "", "Directory SecretTemplates can read file inputs from, within the subdirectory named after their namespace (empty disables file inputs)")
+	flag.StringVar(&vaultAddress, "vault-address", "", "Address of the Vault server SecretTemplates can read inputs from and push secrets to (empty disables vault inputs and push targets)")
+	flag.StringVar(&vaultAuthMount, "vault-auth-mount", "kubernetes", "Mount path of the Vault Kubernetes auth method")
+	flag.StringVar(&vaultCACert, "vault-ca-cert", "", "Path of a PEM encoded CA bundle used to verify the Vault server certificate")
+	flag.StringVar(&vaultNamespace, "vault-namespace", "", "Vault Enterprise namespace")
+	flag.StringVar(&externalProviderDirectory, "external-provider-directory", "", "Directory containing the Unix sockets of external providers (empty disables external inputs)")
+	flag.DurationVar(&externalProviderTimeout, "external-provider-timeout", 10*time.Second, "Timeout of requests to external providers")
+	flag.BoolVar(&pauseReconciliation, "pause-reconciliation", false, "Suspend reconciliation of all SecretTemplates, leaving their Secrets untouched")
+	flag.BoolVar(&enablePolicies, "enable-secret-template-policies", false, "Restrict the input resources SecretTemplates can read by SecretTemplatePolicies (requires the SecretTemplatePolicy CRD)")
+	flag.BoolVar(&enableEncryptedConfigMaps, "enable-encrypted-configmaps", false, "Allow SecretTemplates to store encrypted copies of Secrets in ConfigMaps (requires permission to manage ConfigMaps)")
+	flag.BoolVar(&impersonateServiceAccounts, "service-account-impersonation", false, "Read input resources by impersonating the ServiceAccounts of SecretTemplates instead of requesting tokens for them (requires the impersonate permission)")
+	flag.DurationVar(&tokenExpiration, "service-account-token-expiration", time.Hour, "Lifetime of the tokens requested for the ServiceAccounts of SecretTemplates to read input resources and to authenticate to Vault, external providers and HTTP push targets, at least 10m")
+	flag.StringVar(&tokenAudiences, "service-account-token-audiences", "", "Comma-separated list of audiences of the tokens requested for the ServiceAccounts of SecretTemplates to read input resources and to log in to Vault (empty for the audiences of the API server)")
+	flag.DurationVar(&issuedTokenMaxExpiration, "issued-token-max-expiration", time.Hour, "Maximum lifetime of the ServiceAccount tokens SecretTemplates write into Secrets through serviceAccountToken inputs and kubeconfigs")
+	flag.StringVar(&issuedTokenAudiences, "issued-token-audiences", "templatedsecret.starstreak.dev/service-account-token", "Comma-separated list of audiences of the tokens of serviceAccountToken inputs that do not set any")
+	flag.StringVar(&httpPushURLPrefixes, "http-push-url-prefixes", "", "Comma-separated list of URL prefixes SecretTemplates can push secrets to (empty disables http push targets)")
 	flag.Parse()
 
 	// Set up zap logger with configured log level
@@ -142,18 +178,115 @@ func main() {
 	coreClient, err := kubernetes.NewForConfig(restConfig)
 	exitIfErr(entryLog, "building core client", err)
 
-	saLoader := generator.NewServiceAccountLoader(satoken.NewManager(coreClient, log.WithName("template")))
+	if tokenExpiration < 10*time.Minute {
+		exitIfErr(entryLog, "configuring service account tokens", fmt.Errorf("token expiration %s is shorter than 10m", tokenExpiration))
+	}
+	tokenSettings := generator.TokenSettings{Expiration: tokenExpiration}
+	for _, audience := range strings.Split(tokenAudiences, ",") {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			tokenSettings.Audiences = append(tokenSettings.Audiences, audience)
+		}
+	}
+
+	tokenManager := satoken.NewManager(coreClient, log.WithName("template"))
+	var saLoader *generator.ServiceAccountLoader
+	if impersonateServiceAccounts {
+		saLoader = generator.NewImpersonatingServiceAccountLoader(restConfig, mgr.GetClient())
+		entryLog.Info("reading input resources by impersonating service accounts")
+	} else {
+		saLoader = generator.NewServiceAccountLoader(tokenManager)
+		saLoader.SetConfig(restConfig)
+		saLoader.SetTokenSettings(tokenSettings)
+	}
 
 	// Set SecretTemplate's maximum exponential to reduce reconcile time for inputresource errors
 	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(100*time.Millisecond, 120*time.Second)
 	secretTemplateReconciler := generator.NewSecretTemplateReconciler(mgr, mgr.GetClient(), saLoader, tracker.NewTracker(), log.WithName("template"))
 
+	tokenIssuer := satoken.NewIssuer(coreClient, tokenManager, issuedTokenMaxExpiration)
+	secretTemplateReconciler.SetTokenIssuer(tokenIssuer)
+	secretTemplateReconciler.SetAPIReader(mgr.GetAPIReader())
+	if err := secretTemplateReconciler.SetClusterConfig(restConfig); err != nil {
+		entryLog.Error(err, "kubeconfigs will not default to the cluster the controller runs in")
+	}
+
 	// Pass reconciliation settings to the reconciler
 	secretTemplateReconciler.SetReconciliationSettings(reconciliationInterval, maxSecretAge)
 	entryLog.Info("configured reconciliation settings",
 		"interval", reconciliationInterval.String(),
 		"maxSecretAge", maxSecretAge.String())
 
+	if pauseReconciliation {
+		secretTemplateReconciler.SetPaused(true)
+		entryLog.Info("reconciliation of all SecretTemplates is paused")
+	}
+
+	if enablePolicies {
+		secretTemplateReconciler.SetPoliciesEnabled(true)
+		entryLog.Info("enabled SecretTemplatePolicies")
+	}
+
+	if enableEncryptedConfigMaps {
+		secretTemplateReconciler.SetEncryptedConfigMapsEnabled(true)
+		entryLog.Info("enabled encrypted configmaps")
+	}
+
+	var issuedAudiences []string
+	for _, audience := range strings.Split(issuedTokenAudiences, ",") {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			issuedAudiences = append(issuedAudiences, audience)
+		}
+	}
+	secretTemplateReconciler.AddInputProvider(generator.ServiceAccountTokenInputProvider, satoken.NewInputProvider(tokenIssuer, issuedAudiences))
+
+	if fileInputDirectory != "" {
+		fileInputs, err := fileinput.NewWatcher(fileInputDirectory, log.WithName("fileinput"))
+		exitIfErr(entryLog, "setting up file inputs", err)
+		exitIfErr(entryLog, "setting up file inputs", mgr.Add(fileInputs))
+
+		secretTemplateReconciler.SetFileInputs(fileInputs)
+		entryLog.Info("enabled file inputs", "directory", fileInputDirectory)
+	}
+
+	if vaultAddress != "" {
+		vaultProvider, err := vault.NewProvider(vault.Config{
+			Address:    vaultAddress,
+			AuthMount:  vaultAuthMount,
+			CACertFile: vaultCACert,
+			Namespace:  vaultNamespace,
+			Tokens:     tokenSettings,
+		}, tokenManager)
+		exitIfErr(entryLog, "setting up vault inputs", err)
+
+		secretTemplateReconciler.AddInputProvider(generator.VaultInputProvider, vaultProvider)
+		secretTemplateReconciler.AddPushSink(generator.VaultPushSink, vaultProvider)
+		entryLog.Info("enabled vault inputs and push targets", "address", vaultAddress)
+	}
+
+	if externalProviderDirectory != "" {
+		secretTemplateReconciler.AddInputProvider(generator.ExternalInputProvider, external.NewProvider(external.Config{
+			SocketDir: externalProviderDirectory,
+			Timeout:   externalProviderTimeout,
+			Tokens:    tokenSettings,
+		}, tokenManager))
+		entryLog.Info("enabled external inputs", "directory", externalProviderDirectory)
+	}
+
+	if httpPushURLPrefixes != "" {
+		var prefixes []string
+		for _, prefix := range strings.Split(httpPushURLPrefixes, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+
+		httpSink, err := httppush.NewSink(httppush.Config{AllowedURLPrefixes: prefixes, Tokens: tokenSettings}, tokenManager)
+		exitIfErr(entryLog, "setting up http push targets", err)
+
+		secretTemplateReconciler.AddPushSink(generator.HTTPPushSink, httpSink)
+		entryLog.Info("enabled http push targets", "urlPrefixes", prefixes)
+	}
+
 	exitIfErr(entryLog, "registering", registerCtrlWithRateLimiter("template", mgr, secretTemplateReconciler, rateLimiter))
 
 	entryLog.Info("starting manager")
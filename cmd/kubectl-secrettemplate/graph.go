@@ -0,0 +1,150 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// graphNode is a SecretTemplate along with the input resources it reads and the Secret it writes.
+type graphNode struct {
+	name   string
+	secret string
+	inputs []graphInput
+}
+
+type graphInput struct {
+	name   string
+	source string
+	absent bool
+	// writtenBy is the SecretTemplate writing the Secret read as input, if any.
+	writtenBy string
+}
+
+func graph(ctx context.Context, opts options) error {
+	if opts.output != "text" && opts.output != "dot" {
+		return fmt.Errorf("unsupported output format %q, must be one of text or dot", opts.output)
+	}
+
+	secretTemplates, err := opts.client.TemplatedsecretV1alpha1().SecretTemplates(opts.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing SecretTemplates: %w", err)
+	}
+
+	nodes := graphNodes(secretTemplates.Items)
+	if opts.output == "dot" {
+		writeDot(opts.stdout, nodes)
+	} else {
+		writeGraph(opts.stdout, nodes)
+	}
+	return nil
+}
+
+func graphNodes(secretTemplates []tsv1alpha1.SecretTemplate) []graphNode {
+	// writers holds the SecretTemplate writing each Secret.
+	writers := map[string]string{}
+	for _, secretTemplate := range secretTemplates {
+		writers[secretNode(secretTemplate.Namespace, outputSecret(secretTemplate))] = templateNode(secretTemplate.Namespace, secretTemplate.Name)
+	}
+
+	var nodes []graphNode
+	for _, secretTemplate := range secretTemplates {
+		node := graphNode{
+			name:   templateNode(secretTemplate.Namespace, secretTemplate.Name),
+			secret: secretNode(secretTemplate.Namespace, outputSecret(secretTemplate)),
+		}
+
+		for _, input := range secretTemplate.Spec.InputResources {
+			source := inputSource(secretTemplate.Namespace, input)
+			node.inputs = append(node.inputs, graphInput{
+				name:      input.Name,
+				source:    source,
+				absent:    slices.Contains(secretTemplate.Status.AbsentInputResources, input.Name),
+				writtenBy: writers[source],
+			})
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// outputSecret returns the name of the Secret a SecretTemplate writes, which is named like the SecretTemplate.
+func outputSecret(secretTemplate tsv1alpha1.SecretTemplate) string {
+	if secretTemplate.Status.Secret.Name != "" {
+		return secretTemplate.Status.Secret.Name
+	}
+	return secretTemplate.Name
+}
+
+func templateNode(namespace, name string) string {
+	return fmt.Sprintf("SecretTemplate %s/%s", namespace, name)
+}
+
+func secretNode(namespace, name string) string {
+	return fmt.Sprintf("Secret %s/%s", namespace, name)
+}
+
+// inputSource describes where an input resource is read from. Names containing expressions are shown as written.
+func inputSource(namespace string, input tsv1alpha1.InputResource) string {
+	switch {
+	case input.File != nil:
+		return "file " + input.File.Path
+	case input.Vault != nil:
+		mount := input.Vault.Mount
+		if mount == "" {
+			mount = "secret"
+		}
+		return fmt.Sprintf("vault %s/%s", mount, input.Vault.Path)
+	case input.External != nil:
+		return "external provider " + input.External.Provider
+	case input.ServiceAccountToken != nil:
+		return "token of ServiceAccount " + orDash(input.ServiceAccountToken.ServiceAccountName)
+	case input.Ref.Selector != nil:
+		return fmt.Sprintf("%s %s/* matching %s", input.Ref.Kind, namespace, metav1.FormatLabelSelector(input.Ref.Selector))
+	case input.Ref.Kind == "Secret" && input.Ref.APIVersion == "v1":
+		return secretNode(namespace, input.Ref.Name)
+	default:
+		return fmt.Sprintf("%s %s/%s", input.Ref.Kind, namespace, input.Ref.Name)
+	}
+}
+
+func writeGraph(w io.Writer, nodes []graphNode) {
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s -> %s\n", node.name, node.secret)
+		for _, input := range node.inputs {
+			line := fmt.Sprintf("  %s: %s", input.name, input.source)
+			if input.writtenBy != "" {
+				line += fmt.Sprintf(" (written by %s)", input.writtenBy)
+			}
+			if input.absent {
+				line += " (absent)"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// writeDot writes the graph in the DOT language of Graphviz. Secrets written by one SecretTemplate and read by another
+// are a single node, connecting the two.
+func writeDot(w io.Writer, nodes []graphNode) {
+	fmt.Fprintln(w, "digraph secrettemplates {")
+	for _, node := range nodes {
+		fmt.Fprintf(w, "  %q [shape=box];\n", node.name)
+		fmt.Fprintf(w, "  %q -> %q;\n", node.name, node.secret)
+		for _, input := range node.inputs {
+			style := ""
+			if input.absent {
+				style = ", style=dashed"
+			}
+			fmt.Fprintf(w, "  %q -> %q [label=%q%s];\n", input.source, node.name, input.name, style)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Graph(t *testing.T) {
+	// db writes the Secret db-creds, which app reads along with inputs of every other source.
+	db := secretTemplate("app", "db", tsv1alpha1.SecretTemplateStatus{Secret: corev1.LocalObjectReference{Name: "db-creds"}}, "root")
+	app := secretTemplate("app", "app", tsv1alpha1.SecretTemplateStatus{AbsentInputResources: []string{"replica"}}, "db-creds", "replica")
+	app.Spec.InputResources = append(app.Spec.InputResources,
+		tsv1alpha1.InputResource{Name: "config", Ref: tsv1alpha1.InputResourceRef{APIVersion: "v1", Kind: "ConfigMap", Name: "config"}},
+		tsv1alpha1.InputResource{Name: "peers", Ref: tsv1alpha1.InputResourceRef{APIVersion: "v1", Kind: "Secret", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "peer"}}}},
+		tsv1alpha1.InputResource{Name: "cert", File: &tsv1alpha1.FileInputSource{Path: "tls/cert.pem"}},
+		tsv1alpha1.InputResource{Name: "kv", Vault: &tsv1alpha1.VaultInputSource{Path: "app/db"}},
+		tsv1alpha1.InputResource{Name: "hsm", External: &tsv1alpha1.ExternalInputSource{Provider: "hsm"}},
+		tsv1alpha1.InputResource{Name: "token", ServiceAccountToken: &tsv1alpha1.ServiceAccountTokenInputSource{ServiceAccountName: "app"}},
+	)
+	other := secretTemplate("ops", "other", tsv1alpha1.SecretTemplateStatus{}, "db-creds")
+	client := fake.NewSimpleClientset(db, app, other)
+
+	tests := []struct {
+		name      string
+		namespace string
+		output    string
+		expected  string
+	}{
+		{
+			name:      "text",
+			namespace: "app",
+			output:    "text",
+			expected: `SecretTemplate app/app -> Secret app/app
+  db-creds: Secret app/db-creds (written by SecretTemplate app/db)
+  replica: Secret app/replica (absent)
+  config: ConfigMap app/config
+  peers: Secret app/* matching role=peer
+  cert: file tls/cert.pem
+  kv: vault secret/app/db
+  hsm: external provider hsm
+  token: token of ServiceAccount app
+SecretTemplate app/db -> Secret app/db-creds
+  root: Secret app/root
+`,
+		},
+		{
+			name:      "dot",
+			namespace: "app",
+			output:    "dot",
+			expected: `digraph secrettemplates {
+  "SecretTemplate app/app" [shape=box];
+  "SecretTemplate app/app" -> "Secret app/app";
+  "Secret app/db-creds" -> "SecretTemplate app/app" [label="db-creds"];
+  "Secret app/replica" -> "SecretTemplate app/app" [label="replica", style=dashed];
+  "ConfigMap app/config" -> "SecretTemplate app/app" [label="config"];
+  "Secret app/* matching role=peer" -> "SecretTemplate app/app" [label="peers"];
+  "file tls/cert.pem" -> "SecretTemplate app/app" [label="cert"];
+  "vault secret/app/db" -> "SecretTemplate app/app" [label="kv"];
+  "external provider hsm" -> "SecretTemplate app/app" [label="hsm"];
+  "token of ServiceAccount app" -> "SecretTemplate app/app" [label="token"];
+  "SecretTemplate app/db" [shape=box];
+  "SecretTemplate app/db" -> "Secret app/db-creds";
+  "Secret app/root" -> "SecretTemplate app/db" [label="root"];
+}
+`,
+		},
+		{
+			// Secrets are only connected within a namespace.
+			name:      "all namespaces",
+			namespace: metav1.NamespaceAll,
+			output:    "text",
+			expected: `SecretTemplate app/app -> Secret app/app
+  db-creds: Secret app/db-creds (written by SecretTemplate app/db)
+  replica: Secret app/replica (absent)
+  config: ConfigMap app/config
+  peers: Secret app/* matching role=peer
+  cert: file tls/cert.pem
+  kv: vault secret/app/db
+  hsm: external provider hsm
+  token: token of ServiceAccount app
+SecretTemplate app/db -> Secret app/db-creds
+  root: Secret app/root
+SecretTemplate ops/other -> Secret ops/other
+  db-creds: Secret ops/db-creds
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := graph(context.Background(), options{client: client, namespace: tc.namespace, output: tc.output, stdout: &stdout})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+
+	t.Run("unsupported output", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		err := graph(context.Background(), options{client: client, namespace: "app", output: "json", stdout: &bytes.Buffer{}})
+		assert.EqualError(t, err, `unsupported output format "json", must be one of text or dot`)
+		assert.Empty(t, client.Actions())
+	})
+}
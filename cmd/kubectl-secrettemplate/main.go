@@ -0,0 +1,206 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// kubectl-secrettemplate is a kubectl plugin to inspect SecretTemplates and request them to be reconciled.
+// Installed on the PATH, it is run as "kubectl secrettemplate".
+//
+// Usage:
+//
+//	kubectl secrettemplate list [-n <namespace> | -A]
+//	kubectl secrettemplate graph [-n <namespace> | -A] [--output text|dot]
+//	kubectl secrettemplate reconcile <name> [-n <namespace>]
+//	kubectl secrettemplate regenerate <name> [-n <namespace>]
+//
+// list shows every SecretTemplate with the state of its input resources, graph the input resources each SecretTemplate
+// reads and the Secret it writes. reconcile requests a SecretTemplate to be reconciled immediately, regenerate requests
+// its Secret to be regenerated as if it exceeded its maximum age, e.g. to mint new tokens.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/client/clientset/versioned"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		os.Exit(run("list", os.Args[2:], list))
+	case "graph":
+		os.Exit(run("graph", os.Args[2:], graph))
+	case "reconcile":
+		os.Exit(run("reconcile", os.Args[2:], request(generator.ReconcileRequestedAtAnnKey)))
+	case "regenerate":
+		os.Exit(run("regenerate", os.Args[2:], request(generator.RegenerateRequestedAtAnnKey)))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(exitUsage)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  kubectl secrettemplate list [-n <namespace> | -A]")
+	fmt.Fprintln(os.Stderr, "  kubectl secrettemplate graph [-n <namespace> | -A] [--output text|dot]")
+	fmt.Fprintln(os.Stderr, "  kubectl secrettemplate reconcile <name> [-n <namespace>]")
+	fmt.Fprintln(os.Stderr, "  kubectl secrettemplate regenerate <name> [-n <namespace>]")
+}
+
+// options are the flags shared by all commands.
+type options struct {
+	client        versioned.Interface
+	namespace     string
+	allNamespaces bool
+	output        string
+	args          []string
+	stdout        io.Writer
+}
+
+func run(command string, args []string, fn func(context.Context, options) error) int {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	kubeconfig := flags.String("kubeconfig", "", "Path of the kubeconfig file")
+	kubeContext := flags.String("context", "", "Name of the kubeconfig context to use")
+	var opts options
+	flags.StringVar(&opts.namespace, "namespace", "", "Namespace of the SecretTemplates, defaults to the namespace of the kubeconfig context")
+	flags.StringVar(&opts.namespace, "n", "", "Shorthand for --namespace")
+	flags.BoolVar(&opts.allNamespaces, "all-namespaces", false, "Use SecretTemplates of all namespaces")
+	flags.BoolVar(&opts.allNamespaces, "A", false, "Shorthand for --all-namespaces")
+	flags.StringVar(&opts.output, "output", "text", "Output format of graph, one of text or dot")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	opts.args = flags.Args()
+	opts.stdout = os.Stdout
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = *kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: *kubeContext})
+
+	if opts.namespace == "" {
+		namespace, _, err := clientConfig.Namespace()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loading kubeconfig: %s\n", err)
+			return exitUsage
+		}
+		opts.namespace = namespace
+	}
+	if opts.allNamespaces {
+		opts.namespace = metav1.NamespaceAll
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loading kubeconfig: %s\n", err)
+		return exitUsage
+	}
+	opts.client, err = versioned.NewForConfig(restConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "creating client: %s\n", err)
+		return exitUsage
+	}
+
+	if err := fn(context.Background(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	return 0
+}
+
+func list(ctx context.Context, opts options) error {
+	secretTemplates, err := opts.client.TemplatedsecretV1alpha1().SecretTemplates(opts.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing SecretTemplates: %w", err)
+	}
+
+	w := tabwriter.NewWriter(opts.stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSTATUS\tINPUTS\tABSENT\tSECRET\tMESSAGE")
+	for _, secretTemplate := range secretTemplates.Items {
+		status := secretTemplate.Status
+		inputs := len(secretTemplate.Spec.InputResources)
+
+		// Input resources are only known to be resolved once the SecretTemplate was reconciled successfully.
+		resolved := "-"
+		if condition := findCondition(status.Conditions, tsv1alpha1.ReconcileSucceeded); condition != nil && condition.Status == corev1.ConditionTrue {
+			resolved = fmt.Sprintf("%d/%d", inputs-len(status.AbsentInputResources), inputs)
+		}
+
+		var message string
+		if condition := findCondition(status.Conditions, tsv1alpha1.ReconcileFailed); condition != nil && condition.Status == corev1.ConditionTrue {
+			message = condition.Message
+		} else if condition := findCondition(status.Conditions, tsv1alpha1.Suspended); condition != nil && condition.Status == corev1.ConditionTrue {
+			message = condition.Message
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", secretTemplate.Namespace, secretTemplate.Name, orDash(status.FriendlyDescription),
+			resolved, orDash(strings.Join(status.AbsentInputResources, ",")), orDash(status.Secret.Name), message)
+	}
+	return w.Flush()
+}
+
+// request annotates a SecretTemplate with the current time under key, which the controller handles as a request.
+func request(key string) func(context.Context, options) error {
+	return func(ctx context.Context, opts options) error {
+		if len(opts.args) != 1 || opts.allNamespaces {
+			return fmt.Errorf("exactly one SecretTemplate must be named")
+		}
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{key: time.Now().UTC().Format(time.RFC3339Nano)},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		name := opts.args[0]
+		if _, err := opts.client.TemplatedsecretV1alpha1().SecretTemplates(opts.namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("annotating SecretTemplate %s/%s: %w", opts.namespace, name, err)
+		}
+		fmt.Fprintf(opts.stdout, "secrettemplate %s/%s annotated with %s\n", opts.namespace, name, key)
+		return nil
+	}
+}
+
+func findCondition(conditions []tsv1alpha1.Condition, conditionType tsv1alpha1.ConditionType) *tsv1alpha1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
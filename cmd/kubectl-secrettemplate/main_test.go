@@ -0,0 +1,170 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/client/clientset/versioned/fake"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func Test_List(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		secretTemplate("app", "db", tsv1alpha1.SecretTemplateStatus{
+			Secret:               corev1.LocalObjectReference{Name: "db"},
+			AbsentInputResources: []string{"replica"},
+			GenericStatus: tsv1alpha1.GenericStatus{
+				FriendlyDescription: "Reconcile succeeded",
+				Conditions:          []tsv1alpha1.Condition{{Type: tsv1alpha1.ReconcileSucceeded, Status: corev1.ConditionTrue}},
+			},
+		}, "creds", "replica"),
+		secretTemplate("app", "cache", tsv1alpha1.SecretTemplateStatus{
+			GenericStatus: tsv1alpha1.GenericStatus{
+				FriendlyDescription: "Reconcile failed",
+				Conditions:          []tsv1alpha1.Condition{{Type: tsv1alpha1.ReconcileFailed, Status: corev1.ConditionTrue, Message: "secrets \"creds\" not found"}},
+			},
+		}, "creds"),
+		secretTemplate("ops", "frozen", tsv1alpha1.SecretTemplateStatus{
+			GenericStatus: tsv1alpha1.GenericStatus{
+				FriendlyDescription: "Suspended",
+				Conditions:          []tsv1alpha1.Condition{{Type: tsv1alpha1.Suspended, Status: corev1.ConditionTrue, Message: "spec.suspend is set"}},
+			},
+		}),
+		secretTemplate("ops", "new", tsv1alpha1.SecretTemplateStatus{}, "creds"),
+	)
+
+	tests := []struct {
+		name      string
+		namespace string
+		expected  string
+	}{
+		{
+			name:      "namespace",
+			namespace: "app",
+			expected: `NAMESPACE   NAME    STATUS                INPUTS   ABSENT    SECRET   MESSAGE
+app         cache   Reconcile failed      -        -         -        secrets "creds" not found
+app         db      Reconcile succeeded   1/2      replica   db
+`,
+		},
+		{
+			name:      "all namespaces",
+			namespace: metav1.NamespaceAll,
+			expected: `NAMESPACE   NAME     STATUS                INPUTS   ABSENT    SECRET   MESSAGE
+app         cache    Reconcile failed      -        -         -        secrets "creds" not found
+app         db       Reconcile succeeded   1/2      replica   db
+ops         frozen   Suspended             -        -         -        spec.suspend is set
+ops         new      -                     -        -         -
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := list(context.Background(), options{client: client, namespace: tc.namespace, stdout: &stdout})
+			require.NoError(t, err)
+			// Rows without a message end in padding.
+			assert.Equal(t, tc.expected, trimLines(stdout.String()))
+		})
+	}
+}
+
+func Test_Request(t *testing.T) {
+	tests := []struct {
+		name    string
+		command func(context.Context, options) error
+		key     string
+	}{
+		{name: "reconcile", command: request(generator.ReconcileRequestedAtAnnKey), key: generator.ReconcileRequestedAtAnnKey},
+		{name: "regenerate", command: request(generator.RegenerateRequestedAtAnnKey), key: generator.RegenerateRequestedAtAnnKey},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(secretTemplate("app", "db", tsv1alpha1.SecretTemplateStatus{}))
+			var patches []clienttesting.PatchAction
+			client.PrependReactor("patch", "secrettemplates", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				patches = append(patches, action.(clienttesting.PatchAction))
+				return false, nil, nil
+			})
+
+			var stdout bytes.Buffer
+			err := tc.command(context.Background(), options{client: client, namespace: "app", args: []string{"db"}, stdout: &stdout})
+			require.NoError(t, err)
+			assert.Equal(t, "secrettemplate app/db annotated with "+tc.key+"\n", stdout.String())
+
+			require.Len(t, patches, 1)
+			assert.Equal(t, "app", patches[0].GetNamespace())
+			assert.Equal(t, "db", patches[0].GetName())
+			assert.Equal(t, types.MergePatchType, patches[0].GetPatchType())
+
+			var patch map[string]map[string]map[string]string
+			require.NoError(t, json.Unmarshal(patches[0].GetPatch(), &patch))
+			require.Len(t, patch["metadata"]["annotations"], 1)
+			requestedAt, err := time.Parse(time.RFC3339Nano, patch["metadata"]["annotations"][tc.key])
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), requestedAt, time.Minute)
+
+			annotated, err := client.TemplatedsecretV1alpha1().SecretTemplates("app").Get(context.Background(), "db", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, patch["metadata"]["annotations"][tc.key], annotated.Annotations[tc.key])
+		})
+	}
+
+	t.Run("requires exactly one name", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		command := request(generator.ReconcileRequestedAtAnnKey)
+
+		err := command(context.Background(), options{client: client, namespace: "app", stdout: &bytes.Buffer{}})
+		assert.EqualError(t, err, "exactly one SecretTemplate must be named")
+		err = command(context.Background(), options{client: client, namespace: "app", args: []string{"db", "cache"}, stdout: &bytes.Buffer{}})
+		assert.EqualError(t, err, "exactly one SecretTemplate must be named")
+		err = command(context.Background(), options{client: client, allNamespaces: true, args: []string{"db"}, stdout: &bytes.Buffer{}})
+		assert.EqualError(t, err, "exactly one SecretTemplate must be named")
+		assert.Empty(t, client.Actions())
+	})
+
+	t.Run("missing secrettemplate", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+
+		err := request(generator.ReconcileRequestedAtAnnKey)(context.Background(), options{client: client, namespace: "app", args: []string{"db"}, stdout: &bytes.Buffer{}})
+		assert.ErrorContains(t, err, "annotating SecretTemplate app/db: ")
+	})
+}
+
+func secretTemplate(namespace, name string, status tsv1alpha1.SecretTemplateStatus, inputs ...string) *tsv1alpha1.SecretTemplate {
+	secretTemplate := &tsv1alpha1.SecretTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     status,
+	}
+	for _, input := range inputs {
+		secretTemplate.Spec.InputResources = append(secretTemplate.Spec.InputResources, tsv1alpha1.InputResource{
+			Name: input,
+			Ref:  tsv1alpha1.InputResourceRef{APIVersion: "v1", Kind: "Secret", Name: input},
+		})
+	}
+	return secretTemplate
+}
+
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// static-provider is a reference external provider serving secrets from a JSON file.
+// It is meant to run as a sidecar of the controller, sharing the socket directory with it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/drae/templated-secret-controller/pkg/external"
+	"github.com/drae/templated-secret-controller/pkg/external/static"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+)
+
+var (
+	socketPath  = ""
+	secretsFile = ""
+	ttl         = time.Duration(0)
+)
+
+func main() {
+	flag.StringVar(&socketPath, "socket", "/var/run/templated-secret-providers/static.sock", "Path of the Unix socket to serve on")
+	flag.StringVar(&secretsFile, "secrets-file", "", "Path of the JSON file mapping secret names to their data")
+	flag.DurationVar(&ttl, "ttl", 0, "TTL returned with every secret (0 for none)")
+	flag.Parse()
+
+	if secretsFile == "" {
+		fmt.Fprintln(os.Stderr, "--secrets-file must be set")
+		os.Exit(1)
+	}
+
+	resolver := static.Resolver{File: secretsFile, TTL: ttl}
+	if err := external.Serve(signals.SetupSignalHandler(), socketPath, resolver); err != nil {
+		fmt.Fprintf(os.Stderr, "serving: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+)
+
+func explain(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	templateFile := flags.String("template", "", "Path of the SecretTemplate manifest")
+	output := flags.String("output", outputText, "Output format, one of text or json")
+	var inputFiles files
+	flags.Var(&inputFiles, "inputs", "Path of a file containing input resource manifests, can be repeated")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *templateFile == "" {
+		fmt.Fprintln(stderr, "--template must be set")
+		return exitUsage
+	}
+	if *output != outputText && *output != outputJSON {
+		fmt.Fprintf(stderr, "unsupported output format %q, must be one of text or json\n", *output)
+		return exitUsage
+	}
+
+	secretTemplate, objects, err := readTemplateAndInputs(*templateFile, inputFiles)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// The explanation is printed even if rendering fails, as that is when it is needed most.
+	explanations, renderErr := generator.Explain(context.Background(), secretTemplate, objects)
+	if *output == outputJSON {
+		if explanations == nil {
+			explanations = []tsv1alpha1.KeyExplanation{}
+		}
+		if err := writeJSON(stdout, explanations); err != nil {
+			fmt.Fprintf(stderr, "writing explanation: %s\n", err)
+			return exitRenderFailed
+		}
+	} else {
+		writeExplanations(stdout, explanations)
+	}
+
+	if renderErr != nil {
+		fmt.Fprintf(stderr, "rendering %s: %s\n", secretTemplate.Name, renderErr)
+		return exitRenderFailed
+	}
+	return 0
+}
+
+func writeExplanations(w io.Writer, explanations []tsv1alpha1.KeyExplanation) {
+	for _, explanation := range explanations {
+		fmt.Fprintf(w, "%s (%s)\n", explanation.Key, explanation.Source)
+		if explanation.Expression != "" {
+			fmt.Fprintf(w, "  expression: %s\n", explanation.Expression)
+		}
+		if explanation.Input != "" {
+			fmt.Fprintf(w, "  copied from: %s\n", explanation.Input)
+		}
+
+		for _, read := range explanation.Reads {
+			path := read.Path
+			if read.Field != "" {
+				path = read.Field + ": " + path
+			}
+			if read.JSONPath != "" {
+				path += " -> " + read.JSONPath
+			}
+			fmt.Fprintf(w, "  %s\n", path)
+
+			var details []string
+			if read.Input != "" {
+				details = append(details, "input "+read.Input)
+			}
+			if read.InputField != "" {
+				details = append(details, "field "+read.InputField)
+			}
+			if read.Decoded {
+				details = append(details, "base64 decoded")
+			}
+			if len(read.Functions) > 0 {
+				details = append(details, "piped through "+strings.Join(read.Functions, ", "))
+			}
+			if read.Matches == 1 {
+				details = append(details, "1 match")
+			} else {
+				details = append(details, fmt.Sprintf("%d matches", read.Matches))
+			}
+			fmt.Fprintf(w, "    %s\n", strings.Join(details, ", "))
+			if read.Error != "" {
+				fmt.Fprintf(w, "    error: %s\n", read.Error)
+			}
+		}
+
+		if explanation.Length != nil {
+			fmt.Fprintf(w, "  length: %d\n", *explanation.Length)
+		} else {
+			fmt.Fprintln(w, "  length: not rendered")
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputSARIF = "sarif"
+)
+
+// lintFinding is a finding of a SecretTemplate read from a file.
+type lintFinding struct {
+	File           string `json:"file"`
+	SecretTemplate string `json:"secretTemplate"`
+	generator.LintFinding
+}
+
+func lint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("output", outputText, "Output format, one of text, json or sarif")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "at least one file must be given")
+		return exitUsage
+	}
+	if *output != outputText && *output != outputJSON && *output != outputSARIF {
+		fmt.Fprintf(stderr, "unsupported output format %q, must be one of text, json or sarif\n", *output)
+		return exitUsage
+	}
+
+	findings := []lintFinding{}
+	for _, path := range flags.Args() {
+		objects, err := readObjects(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "reading %s: %s\n", path, err)
+			return exitUsage
+		}
+
+		// Other resources, e.g. the input resources of the SecretTemplates, can be kept in the same files.
+		for _, object := range objects {
+			if object.GetKind() != "SecretTemplate" {
+				continue
+			}
+
+			var secretTemplate tsv1alpha1.SecretTemplate
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &secretTemplate); err != nil {
+				fmt.Fprintf(stderr, "reading %s: SecretTemplate %s: %s\n", path, object.GetName(), err)
+				return exitUsage
+			}
+			for _, finding := range generator.Lint(&secretTemplate) {
+				findings = append(findings, lintFinding{File: path, SecretTemplate: secretTemplate.Name, LintFinding: finding})
+			}
+		}
+	}
+
+	var err error
+	switch *output {
+	case outputJSON:
+		err = writeJSON(stdout, findings)
+	case outputSARIF:
+		err = writeJSON(stdout, sarifLog(findings))
+	default:
+		for _, finding := range findings {
+			fmt.Fprintf(stdout, "%s: %s: %s: %s (%s)\n", finding.File, finding.SecretTemplate, finding.Field, finding.Message, finding.Rule)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "writing findings: %s\n", err)
+		return exitUsage
+	}
+
+	if len(findings) > 0 {
+		return exitFindings
+	}
+	return 0
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// The subset of SARIF 2.1.0 needed to report findings, see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type sarif struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func sarifLog(findings []lintFinding) sarif {
+	driver := sarifDriver{Name: "templatedsecret"}
+	for _, id := range slices.Sorted(maps.Keys(generator.LintRules)) {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: generator.LintRules[id]}})
+	}
+
+	results := []sarifResult{}
+	for _, finding := range findings {
+		results = append(results, sarifResult{
+			RuleID:  finding.Rule,
+			Level:   "error",
+			Message: sarifMessage{Text: fmt.Sprintf("%s: %s", finding.Field, finding.Message)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: finding.File}},
+				// Manifests are decoded without line information, the field locates the finding within the SecretTemplate.
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: finding.SecretTemplate + "." + finding.Field, Kind: "member"}},
+			}},
+		})
+	}
+
+	return sarif{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// templatedsecret works with SecretTemplates outside of a cluster, e.g. to test them in CI.
+//
+// Usage:
+//
+//	templatedsecret render --template <file> [--inputs <file>]... [--redact]
+//	templatedsecret explain --template <file> [--inputs <file>]... [--output text|json]
+//	templatedsecret lint [--output text|json|sarif] <file>...
+//
+// render prints the Secret a SecretTemplate produces from input resources read from files. It exits with 1 if the
+// template can not be rendered, and with 2 if the arguments or files are invalid.
+//
+// explain describes how each key of the Secret is resolved: the JSONPaths it reads, the input resources and fields they
+// read, whether base64 encoded values were decoded, and the length of the value. Values are never printed. It exits
+// like render.
+//
+// lint checks the SecretTemplates in the given files without reading their input resources. It exits with 1 if
+// problems were found, and with 2 if the arguments or files are invalid.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	exitRenderFailed = 1
+	exitFindings     = 1
+	exitUsage        = 2
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	switch os.Args[1] {
+	case "render":
+		os.Exit(render(os.Args[2:], os.Stdout, os.Stderr))
+	case "explain":
+		os.Exit(explain(os.Args[2:], os.Stdout, os.Stderr))
+	case "lint":
+		os.Exit(lint(os.Args[2:], os.Stdout, os.Stderr))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(exitUsage)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  templatedsecret render --template <file> [--inputs <file>]... [--redact]")
+	fmt.Fprintln(os.Stderr, "  templatedsecret explain --template <file> [--inputs <file>]... [--output text|json]")
+	fmt.Fprintln(os.Stderr, "  templatedsecret lint [--output text|json|sarif] <file>...")
+}
+
+// files collects the values of a repeated flag.
+type files []string
+
+func (f *files) String() string { return strings.Join(*f, ",") }
+
+func (f *files) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func render(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	templateFile := flags.String("template", "", "Path of the SecretTemplate manifest")
+	redact := flags.Bool("redact", false, "Replace the values of the Secret with <redacted>")
+	var inputFiles files
+	flags.Var(&inputFiles, "inputs", "Path of a file containing input resource manifests, can be repeated")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *templateFile == "" {
+		fmt.Fprintln(stderr, "--template must be set")
+		return exitUsage
+	}
+
+	secretTemplate, objects, err := readTemplateAndInputs(*templateFile, inputFiles)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	secret, err := generator.Render(context.Background(), secretTemplate, objects)
+	if err != nil {
+		fmt.Fprintf(stderr, "rendering %s: %s\n", secretTemplate.Name, err)
+		return exitRenderFailed
+	}
+
+	output, err := yaml.Marshal(renderedSecret(secret, *redact))
+	if err != nil {
+		fmt.Fprintf(stderr, "encoding secret: %s\n", err)
+		return exitRenderFailed
+	}
+	stdout.Write(output)
+	return 0
+}
+
+// readTemplateAndInputs reads a SecretTemplate and the input resources to render it against.
+func readTemplateAndInputs(templateFile string, inputFiles []string) (*tsv1alpha1.SecretTemplate, []*unstructured.Unstructured, error) {
+	secretTemplate, err := readSecretTemplate(templateFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", templateFile, err)
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, path := range inputFiles {
+		read, err := readObjects(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		objects = append(objects, read...)
+	}
+	return secretTemplate, objects, nil
+}
+
+func readSecretTemplate(path string) (*tsv1alpha1.SecretTemplate, error) {
+	objects, err := readObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) != 1 || objects[0].GetKind() != "SecretTemplate" {
+		return nil, fmt.Errorf("expected a single SecretTemplate")
+	}
+
+	var secretTemplate tsv1alpha1.SecretTemplate
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objects[0].Object, &secretTemplate); err != nil {
+		return nil, err
+	}
+	return &secretTemplate, nil
+}
+
+// readObjects reads all YAML or JSON documents of a file.
+func readObjects(path string) ([]*unstructured.Unstructured, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if len(object) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: object}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("document %d is missing apiVersion, kind or metadata.name", len(objects)+1)
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// secretManifest is a Secret without the fields that are empty when rendering offline.
+type secretManifest struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   secretMetadata    `json:"metadata"`
+	Type       corev1.SecretType `json:"type"`
+	Data       map[string][]byte `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+type secretMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func renderedSecret(secret corev1.Secret, redact bool) secretManifest {
+	manifest := secretManifest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: secretMetadata{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	if redact {
+		manifest.Data = nil
+		manifest.StringData = map[string]string{}
+		for key := range secret.Data {
+			manifest.StringData[key] = "<redacted>"
+		}
+	}
+	return manifest
+}
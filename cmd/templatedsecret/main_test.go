@@ -0,0 +1,149 @@
+// Copyright 2024 The Templatedsecret Controller Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplate = `
+apiVersion: templatedsecret.starstreak.dev/v1alpha1
+kind: SecretTemplate
+metadata:
+  name: db
+spec:
+  inputResources:
+  - name: creds
+    ref:
+      apiVersion: v1
+      kind: Secret
+      name: creds
+  template:
+    stringData:
+      password: $(.creds.data.password)
+`
+
+const testInputs = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: p@ss
+`
+
+func Test_Render(t *testing.T) {
+	dir := t.TempDir()
+	templateFile := writeFile(t, dir, "template.yaml", testTemplate)
+	inputsFile := writeFile(t, dir, "inputs.yaml", testInputs)
+	emptyFile := writeFile(t, dir, "empty.yaml", "")
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			name:         "renders the secret",
+			args:         []string{"--template", templateFile, "--inputs", inputsFile},
+			expectedCode: 0,
+			expectedStdout: `apiVersion: v1
+data:
+  password: cEBzcw==
+kind: Secret
+metadata:
+  name: db
+  namespace: default
+type: Opaque
+`,
+		},
+		{
+			name:         "redacts values",
+			args:         []string{"--template", templateFile, "--inputs", inputsFile, "--redact"},
+			expectedCode: 0,
+			expectedStdout: `apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: default
+stringData:
+  password: <redacted>
+type: Opaque
+`,
+		},
+		{
+			name:           "fails to render without input resources",
+			args:           []string{"--template", templateFile},
+			expectedCode:   exitRenderFailed,
+			expectedStderr: "rendering db: cannot fetch input resource creds: secrets \"creds\" not found\n",
+		},
+		{
+			name:           "requires a template",
+			args:           []string{"--inputs", inputsFile},
+			expectedCode:   exitUsage,
+			expectedStderr: "--template must be set\n",
+		},
+		{
+			name:           "rejects files without a secret template",
+			args:           []string{"--template", emptyFile},
+			expectedCode:   exitUsage,
+			expectedStderr: "reading " + emptyFile + ": expected a single SecretTemplate\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := render(tc.args, &stdout, &stderr)
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+			assert.Equal(t, tc.expectedStderr, stderr.String())
+		})
+	}
+}
+
+func Test_Lint(t *testing.T) {
+	dir := t.TempDir()
+	validFile := writeFile(t, dir, "valid.yaml", testTemplate)
+	invalidFile := writeFile(t, dir, "invalid.yaml", `
+apiVersion: templatedsecret.starstreak.dev/v1alpha1
+kind: SecretTemplate
+metadata:
+  name: db
+spec:
+  inputResources:
+  - name: service
+    ref:
+      apiVersion: v1
+      kind: Service
+      name: db
+  template:
+    stringData:
+      host: $(.service.spec.clusterIP)
+`)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, lint([]string{validFile}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	assert.Equal(t, exitFindings, lint([]string{invalidFile}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "(secret-only)")
+
+	assert.Equal(t, exitUsage, lint(nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, lint([]string{"--output", "xml", validFile}, &stdout, &stderr))
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
@@ -14,6 +14,9 @@ const (
 	ReconcileFailed    ConditionType = "ReconcileFailed"
 	ReconcileSucceeded ConditionType = "ReconcileSucceeded"
 
+	// Suspended indicates that reconciliation is suspended and the Secret is left untouched.
+	Suspended ConditionType = "Suspended"
+
 	// Invalid indicates that CRD's spec is not of valid form.
 	Invalid ConditionType = "Invalid"
 )
@@ -22,6 +22,8 @@ func init() {
 		scheme.AddKnownTypes(SchemeGroupVersion,
 			&SecretTemplate{},
 			&SecretTemplateList{},
+			&SecretTemplatePolicy{},
+			&SecretTemplatePolicyList{},
 		)
 		scheme.AddKnownTypes(SchemeGroupVersion, &metav1.Status{})
 		metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -49,7 +49,7 @@ type SecretTemplateList struct {
 // SecretTemplateSpec contains spec information
 type SecretTemplateSpec struct {
 	// A list of input resources that are used to construct a new secret. Input Resources can refer to ANY Kubernetes API.
-	// If loading more than Secrets types ensure that `.spec.ServiceAccountName` is set to an appropriate value.
+	// If loading more than Secrets types ensure that `.spec.serviceAccount` or `.spec.serviceAccountName` is set to an appropriate value.
 	// Input resources are read in the order they are defined. An Input resource's name can be evaluated dynamically from data in a previously evaluated input resource.
 	InputResources []InputResource `json:"inputResources"`
 
@@ -58,16 +58,97 @@ type SecretTemplateSpec struct {
 	JSONPathTemplate *JSONPathTemplate `json:"template,omitempty"`
 
 	// The Service Account used to read InputResources. If not specified, only Secrets can be read as InputResources.
+	// Superseded by serviceAccount, which takes precedence if both are set.
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// The Service Account used to read InputResources, along with the audiences and lifetime of the tokens requested for it.
+	// Alternative to serviceAccountName for clusters with non-default API server audiences or strict token lifetimes.
+	// +optional
+	ServiceAccount *SecretTemplateServiceAccount `json:"serviceAccount,omitempty"`
+
+	// Targets outside of the cluster the data of the Secret is also written to, e.g. a Vault KV secrets engine.
+	// Targets are checked for drift on every reconciliation and overwritten if their data differs.
+	// +optional
+	Push []PushTarget `json:"push,omitempty"`
+
+	// Additionally stores the Secret encrypted to age or OpenPGP recipients, e.g. to export it to Git.
+	// +optional
+	EncryptedOutput *EncryptedOutput `json:"encryptedOutput,omitempty"`
+
+	// Renders the Secret without writing it, e.g. to check the result before taking over an existing Secret.
+	// The differences to the existing Secret are reported in `.status.preview`. Push targets and encrypted copies are not written either.
+	// +optional
+	Preview bool `json:"preview,omitempty"`
+
+	// Suspends reconciliation, e.g. to freeze the Secret during an incident. Input resources are neither read nor tracked
+	// and the Secret is left untouched until suspend is unset.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// SecretTemplateServiceAccount refers to the Service Account used to read InputResources.
+type SecretTemplateServiceAccount struct {
+	// Name of the Service Account in the namespace of the SecretTemplate.
+	Name string `json:"name"`
+	// Audiences of the tokens requested for the Service Account. Defaults to the audiences configured for the controller.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+	// Requested lifetime of the tokens requested for the Service Account. Defaults to the lifetime configured for the controller.
+	// +kubebuilder:validation:Minimum=600
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
+// GetServiceAccount returns the Service Account used to read InputResources, either .spec.serviceAccount or the one named
+// by .spec.serviceAccountName, or nil if neither is set.
+func (s SecretTemplateSpec) GetServiceAccount() *SecretTemplateServiceAccount {
+	switch {
+	case s.ServiceAccount != nil:
+		return s.ServiceAccount
+	case s.ServiceAccountName != "":
+		return &SecretTemplateServiceAccount{Name: s.ServiceAccountName}
+	default:
+		return nil
+	}
+}
+
+// GetServiceAccountName returns the name of the Service Account used to read InputResources, or "" if none is set.
+func (s SecretTemplateSpec) GetServiceAccountName() string {
+	if sa := s.GetServiceAccount(); sa != nil {
+		return sa.Name
+	}
+	return ""
 }
 
 // InputResource is references a single Kubernetes resource along with a identifying name
 type InputResource struct {
 	// The name of InputResource. This is used as the identifying name in templating to refer to this Input Resource.
 	Name string `json:"name"`
-	// The reference to the Input Resource
-	Ref InputResourceRef `json:"ref"`
+	// The reference to the Input Resource. Exactly one of ref, file, vault, external or serviceAccountToken must be set.
+	// +optional
+	Ref InputResourceRef `json:"ref,omitempty"`
+	// Reads the input from a file mounted into the controller, for example by a CSI driver or a sidecar.
+	// Exactly one of ref, file, vault, external or serviceAccountToken must be set.
+	// +optional
+	File *FileInputSource `json:"file,omitempty"`
+	// Reads the input from a HashiCorp Vault KV secrets engine. Requires `.spec.serviceAccountName` to be set.
+	// Exactly one of ref, file, vault, external or serviceAccountToken must be set.
+	// +optional
+	Vault *VaultInputSource `json:"vault,omitempty"`
+	// Reads the input from an external provider running alongside the controller.
+	// Exactly one of ref, file, vault, external or serviceAccountToken must be set.
+	// +optional
+	External *ExternalInputSource `json:"external,omitempty"`
+	// Requests a bound token for a ServiceAccount in the namespace of the SecretTemplate. Requires `.spec.serviceAccountName` to be set.
+	// Exactly one of ref, file, vault, external or serviceAccountToken must be set.
+	// +optional
+	ServiceAccountToken *ServiceAccountTokenInputSource `json:"serviceAccountToken,omitempty"`
+	// Optional input resources that do not exist are left out when templating instead of failing reconciliation.
+	// Expressions referring to them can provide a fallback value using the default function, e.g. $(.input1.data.port | default:5432).
+	// The secret is updated once the input resource exists.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 // InputResourceRef refers to a single Kubernetes resource
@@ -77,27 +158,191 @@ type InputResourceRef struct {
 
 	// The name of the input resource. This field can itself contain JSONPATH syntax to load the name dynamically
 	// from other input resources. For example this field could be set to a static value of "my-secret" or a dynamic valid of "$(.anotherinputresource.spec.name)".
+	// Exactly one of name or selector must be set.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selects all resources of the given kind in the namespace of the SecretTemplate that match the label selector.
+	// The matching resources are available to templates as an array sorted by name, e.g. $(.brokers[*].metadata.name | join:',').
+	// Secrets that start or stop matching update the Secret right away, other kinds are picked up by the next periodic reconciliation.
+	// Exactly one of name or selector must be set.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// FileInputSource refers to a file mounted into the controller. The file must reside in the directory
+// the controller allows file inputs to be read from. Its content is available to templates as text,
+// e.g. $(.input1.content).
+type FileInputSource struct {
+	// Path of the file, either absolute or relative to the directory file inputs are read from.
+	Path string `json:"path"`
+}
+
+// VaultInputSource refers to a secret stored in a HashiCorp Vault KV secrets engine. The fields of the secret
+// are available to templates under the name of the input resource, e.g. $(.input1.password).
+type VaultInputSource struct {
+	// The Vault role used to log in with the Kubernetes auth method, using a token of the SecretTemplate's Service Account.
+	Role string `json:"role"`
+	// Mount path of the KV secrets engine. Defaults to "secret".
+	// +optional
+	Mount string `json:"mount,omitempty"`
+	// Path of the secret within the KV secrets engine.
+	Path string `json:"path"`
+	// Version of the KV secrets engine, either 1 or 2. Defaults to 2.
+	// +optional
+	KVVersion int `json:"kvVersion,omitempty"`
+	// Version of the secret to read. Only supported by version 2 of the KV secrets engine. Defaults to the latest version.
+	// +optional
+	Version int `json:"version,omitempty"`
+}
+
+// ExternalInputSource refers to an input resolved by an external provider. The data returned by the provider
+// is available to templates under the name of the input resource, e.g. $(.input1.password).
+type ExternalInputSource struct {
+	// Name of the provider. The controller reaches the provider on the Unix socket <name>.sock in its provider directory.
+	Provider string `json:"provider"`
+	// Provider specific configuration, e.g. the path of a secret.
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// ServiceAccountTokenInputSource requests a bound token for a ServiceAccount. The token and its expiration time are
+// available to templates as $(.input1.token) and $(.input1.expirationTimestamp). The SecretTemplate's ServiceAccount must
+// be allowed to create tokens for the ServiceAccount, even if it is its own, as the token is written into the Secret.
+// The token is requested again before it expires.
+type ServiceAccountTokenInputSource struct {
+	// Name of the ServiceAccount. Defaults to the ServiceAccount of the SecretTemplate.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Audiences of the token. Defaults to the audiences configured by the controller's --issued-token-audiences flag.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+	// Requested lifetime of the token, at most the controller's --issued-token-max-expiration. Defaults to 3600.
+	// +kubebuilder:validation:Minimum=600
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
+// PushTarget is an external store the data of the Secret is written to.
+type PushTarget struct {
+	// The identifying name of the target, used to report its status.
 	Name string `json:"name"`
+	// Writes the data to a HashiCorp Vault KV secrets engine. Requires `.spec.serviceAccountName` to be set.
+	// Exactly one of vault or http must be set.
+	// +optional
+	Vault *VaultPushTarget `json:"vault,omitempty"`
+	// Writes the data to an HTTP endpoint. Exactly one of vault or http must be set.
+	// +optional
+	HTTP *HTTPPushTarget `json:"http,omitempty"`
+}
+
+// VaultPushTarget refers to a secret in a HashiCorp Vault KV secrets engine. All keys of the Secret are written
+// as fields of the Vault secret.
+type VaultPushTarget struct {
+	// The Vault role used to log in with the Kubernetes auth method, using a token of the SecretTemplate's Service Account.
+	Role string `json:"role"`
+	// Mount path of the KV secrets engine. Defaults to "secret".
+	// +optional
+	Mount string `json:"mount,omitempty"`
+	// Path of the secret within the KV secrets engine.
+	Path string `json:"path"`
+	// Version of the KV secrets engine, either 1 or 2. Defaults to 2.
+	// +optional
+	KVVersion int `json:"kvVersion,omitempty"`
+}
+
+// HTTPPushTarget refers to an HTTP endpoint storing the data of the Secret. The data is written with a PUT request
+// and read back with a GET request, both carrying a JSON object of the form {"data": {"key": "value"}}.
+type HTTPPushTarget struct {
+	// URL of the endpoint. Must be allowed by the controller's --http-push-url-prefixes flag.
+	URL string `json:"url"`
+	// Audience of the Service Account token sent to the endpoint. Defaults to the URL of the endpoint without its query.
+	// Tokens are never requested for the audiences of the API server, so that endpoints can not use them against the cluster.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+}
+
+// EncryptedOutput describes an encrypted copy of the Secret. The Secret is encrypted as a Kubernetes manifest
+// containing its name, namespace, type and data, so that it can be applied once decrypted.
+// The encrypted copy is only renewed when the data of the Secret or the recipients change.
+type EncryptedOutput struct {
+	// Format of the encryption, either age or pgp.
+	Format string `json:"format"`
+	// Public keys of the recipients, which can contain a JSONPATH syntax surrounded by $( ) to read them from an input resource,
+	// e.g. $(.recipients.data.keys). For age, one public key per line. For pgp, one or more ASCII armored public keys.
+	Recipients string `json:"recipients"`
+	// Name of a ConfigMap, in the namespace of the SecretTemplate, the encrypted copy is stored in.
+	// If not set, the encrypted copy is stored in the Secret itself.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Key the encrypted copy is stored under. Defaults to secret.age or secret.asc depending on the format.
+	// +optional
+	Key string `json:"key,omitempty"`
 }
 
 // JSONPathTemplate contains templating information used to construct a new secret
 type JSONPathTemplate struct {
 	// StringData key and value. Where key is the Secret Key and the value can contain a JSONPATH syntax surrounded by $( ).
-	// All InputResources are available via their identifying name.
+	// All InputResources are available via their identifying name. Values read from base64 encoded fields
+	// (Secret `data` and ConfigMap `binaryData`) are decoded. Values can be piped through functions, e.g. $(.input1.spec.value1 | b64dec).
 	// For example:
 	//   key1: static-text
 	//   key2: $(.input1.spec.value1)
 	//   key3: combined-$(.input2.status.value2)-$(.input2.status.value3)
 	// +optional
 	StringData map[string]string `json:"stringData,omitempty"`
-	// Data key and value. Where key is the Secret Key and the value is a jsonpath surrounded by $( ). The fetched data MUST be base64 encoded.
+	// Data key and value. Where key is the Secret Key and the value is a jsonpath surrounded by $( ).
+	// Values read from base64 encoded fields (Secret `data` and ConfigMap `binaryData`) are used as-is. Any other value MUST be base64 encoded,
+	// e.g. by piping it through the b64enc function. The two can not be combined in the same value.
 	// All InputResources are available via their identifying name.
 	// For example:
 	//   key1: $(.secretinput1.data.value1)
-	//   key2: $(.secretinput2.data.value2)
+	//   key2: $(.configmapinput2.data.value2 | b64enc)
 	// +optional
 	Data map[string]string `json:"data,omitempty"`
 
+	// DataFrom copies all keys of Secrets and ConfigMaps read as input resources into the Secret.
+	// When an input resource selects multiple resources, their keys are merged in order, later resources taking precedence.
+	// Keys defined in data, stringData or uris take precedence over keys copied from input resources.
+	// +optional
+	DataFrom []DataFromSource `json:"dataFrom,omitempty"`
+
+	// URIs key and value. Where key is the Secret Key and the value describes a URI composed from individually templated components.
+	// Each component is percent-encoded as required by its position in the URI, so values such as passwords containing `@`, `/` or `%` are safe to use.
+	// For example:
+	//   url:
+	//     scheme: postgres
+	//     username: $(.creds.data.username)
+	//     password: $(.creds.data.password)
+	//     host: $(.service.spec.clusterIP)
+	//     port: $(.service.spec.ports[?(@.name=="tcp-postgresql")].port)
+	//     path: postgres
+	// +optional
+	URIs map[string]URITemplate `json:"uris,omitempty"`
+
+	// JWTs key and value. Where key is the Secret Key and the value describes a JSON Web Token signed by the controller.
+	// Tokens are reused until they pass the refresh point of their lifetime, or their signing key or claims change.
+	// For example:
+	//   token:
+	//     algorithm: RS256
+	//     signingKey: $(.signing.data.key)
+	//     claims:
+	//       iss: templated-secret-controller
+	//       sub: $(.app.metadata.name)
+	//     lifetime: 1h
+	// +optional
+	JWTs map[string]JWTTemplate `json:"jwts,omitempty"`
+
+	// Kubeconfigs key and value. Where key is the Secret Key and the value describes a kubeconfig with a single cluster, user and context.
+	// For example:
+	//   kubeconfig:
+	//     server: https://api.example.com:6443
+	//     certificateAuthority: $(.ca.data.ca\.crt)
+	//     serviceAccountToken:
+	//       expirationSeconds: 3600
+	// +optional
+	Kubeconfigs map[string]KubeconfigTemplate `json:"kubeconfigs,omitempty"`
+
 	// Type is the type of Kubernetes Secret
 	// +optional
 	Type corev1.SecretType `json:"type,omitempty"`
@@ -107,6 +352,95 @@ type JSONPathTemplate struct {
 	Metadata SecretTemplateMetadata `json:"metadata,omitempty"`
 }
 
+// DataFromSource refers to an input resource whose keys are copied into the Secret.
+type DataFromSource struct {
+	// The identifying name of the input resource to copy keys from.
+	InputResource string `json:"inputResource"`
+	// Prefix added to every copied key.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// URITemplate describes a URI whose components can each contain a JSONPATH syntax surrounded by $( ).
+type URITemplate struct {
+	// Scheme of the URI, for example postgres, mysql, redis or mongodb.
+	Scheme string `json:"scheme"`
+	// Username placed in the userinfo section of the URI.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// Password placed in the userinfo section of the URI. Without a username the userinfo is ":<password>", e.g. for Redis AUTH.
+	// +optional
+	Password string `json:"password,omitempty"`
+	// Host name or IP address, or a list of hosts with ports like a:27017,b:27017. IPv6 addresses are bracketed automatically.
+	Host string `json:"host"`
+	// Port number.
+	// +optional
+	Port string `json:"port,omitempty"`
+	// Path of the URI, for example a database name. A leading "/" is added if missing.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Query parameters of the URI. Parameters are encoded sorted by key.
+	// +optional
+	Query map[string]string `json:"query,omitempty"`
+}
+
+// JWTTemplate describes a JSON Web Token whose signing key and claims can contain a JSONPATH syntax surrounded by $( ).
+type JWTTemplate struct {
+	// Algorithm used to sign the token, one of HS256, RS256, ES256 or EdDSA.
+	Algorithm string `json:"algorithm"`
+	// Key used to sign the token. The shared secret for HS256, otherwise a PEM encoded private key.
+	SigningKey string `json:"signingKey"`
+	// Key ID placed in the kid header of the token.
+	// +optional
+	KeyID string `json:"keyID,omitempty"`
+	// Claims of the token. The iat and exp claims are set by the controller.
+	// +optional
+	Claims map[string]string `json:"claims,omitempty"`
+	// Lifetime of the token. Tokens without a lifetime do not expire.
+	// +optional
+	Lifetime *metav1.Duration `json:"lifetime,omitempty"`
+	// Percentage of the lifetime after which a new token is minted. Defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	RefreshPercent int32 `json:"refreshPercent,omitempty"`
+}
+
+// KubeconfigTemplate describes a kubeconfig whose fields can contain a JSONPATH syntax surrounded by $( ).
+type KubeconfigTemplate struct {
+	// URL of the API server. Defaults to the API server of the cluster the controller runs in.
+	// +optional
+	Server string `json:"server,omitempty"`
+	// PEM encoded certificate authority of the API server. Defaults to the certificate authority of the cluster the controller
+	// runs in when no server is set.
+	// +optional
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+	// Name of the cluster, user and context. Defaults to the name of the SecretTemplate.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Default namespace of the context.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Bearer token of the user. Exactly one of token or serviceAccountToken must be set.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// Requests a bound token for the ServiceAccount of the SecretTemplate, which must be allowed to create tokens for
+	// itself. The token is refreshed before it expires.
+	// +optional
+	ServiceAccountToken *KubeconfigServiceAccountToken `json:"serviceAccountToken,omitempty"`
+}
+
+// KubeconfigServiceAccountToken describes a token requested for the ServiceAccount of a SecretTemplate.
+type KubeconfigServiceAccountToken struct {
+	// Audiences of the token. Defaults to the audiences of the API server.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+	// Requested lifetime of the token, at most the controller's --issued-token-max-expiration. Defaults to 3600.
+	// +kubebuilder:validation:Minimum=600
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
 // SecretTemplateMetadata allows the generated secret to contain metadata
 type SecretTemplateMetadata struct {
 	// Annotations to be placed on the generated secret
@@ -125,4 +459,121 @@ type SecretTemplateStatus struct {
 	GenericStatus `json:",inline"`
 	// +optional
 	ObservedSecretResourceVersion string `json:"observedSecretResourceVersion,omitempty"`
+	// Names of optional input resources that did not exist when the secret was last templated.
+	// +optional
+	AbsentInputResources []string `json:"absentInputResources,omitempty"`
+	// Status of the targets the data of the secret is pushed to.
+	// +optional
+	Push []PushTargetStatus `json:"push,omitempty"`
+	// Differences between the rendered and the existing Secret, reported while `.spec.preview` is set.
+	// +optional
+	Preview *SecretPreview `json:"preview,omitempty"`
+	// How each key of the Secret was resolved, reported while the SecretTemplate is annotated with
+	// templatedsecret.starstreak.dev/explain: "true". Values are never included, only their length.
+	// +optional
+	Explanation []KeyExplanation `json:"explanation,omitempty"`
+	// The value of the templatedsecret.starstreak.dev/reconcile-requested-at annotation when the SecretTemplate was last reconciled on request.
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+	// The value of the templatedsecret.starstreak.dev/regenerate-requested-at annotation when the Secret was last regenerated on request.
+	// +optional
+	LastHandledRegenerateAt string `json:"lastHandledRegenerateAt,omitempty"`
+}
+
+// KeyExplanation describes how a key of the Secret was resolved.
+type KeyExplanation struct {
+	// The key of the Secret.
+	Key string `json:"key"`
+	// The field of the template defining the key, one of data, stringData, uris, jwts, kubeconfigs or dataFrom.
+	Source string `json:"source"`
+	// The expression as written, for keys defined in data or stringData.
+	// +optional
+	Expression string `json:"expression,omitempty"`
+	// The input resource the key was copied from, for keys copied using dataFrom.
+	// +optional
+	Input string `json:"input,omitempty"`
+	// The JSONPaths evaluated to resolve the key.
+	// +optional
+	Reads []JSONPathRead `json:"reads,omitempty"`
+	// Length of the value in bytes. Not set if the Secret could not be rendered.
+	// +optional
+	Length *int `json:"length,omitempty"`
+}
+
+// JSONPathRead describes a single $( ) segment of an expression and what it read.
+type JSONPathRead struct {
+	// The field of a composed key the segment is part of, e.g. host for a URI or claims[sub] for a JWT.
+	// +optional
+	Field string `json:"field,omitempty"`
+	// The JSONPath as written within $( ).
+	Path string `json:"path"`
+	// The JSONPath as passed to the Kubernetes JSONPath library.
+	JSONPath string `json:"jsonPath"`
+	// The input resource the JSONPath reads.
+	// +optional
+	Input string `json:"input,omitempty"`
+	// The top level field of the input resource the JSONPath reads, e.g. data.
+	// +optional
+	InputField string `json:"inputField,omitempty"`
+	// Whether the JSONPath read a base64 encoded field, e.g. the data of a Secret, whose values were decoded before templating.
+	Decoded bool `json:"decoded"`
+	// The functions the values were piped through.
+	// +optional
+	Functions []string `json:"functions,omitempty"`
+	// The number of values the JSONPath matched.
+	Matches int `json:"matches"`
+	// Why the JSONPath could not be evaluated, e.g. because a key does not exist.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// SecretPreview describes how the Secret would change if it was written. Values are deliberately never included, not
+// even as digests: unsalted hashes of low-entropy values such as passwords or PINs can be reversed by anyone able to read
+// the status, and salted or keyed digests could not be recomputed by that reader, so they would add nothing to the list
+// of changed keys.
+type SecretPreview struct {
+	// Whether the Secret does not exist yet and would be created.
+	Create bool `json:"create"`
+	// Changes to the keys of the Secret.
+	// +optional
+	Data KeyChanges `json:"data,omitempty"`
+	// Changes to the labels of the Secret.
+	// +optional
+	Labels KeyChanges `json:"labels,omitempty"`
+	// Changes to the annotations of the Secret.
+	// +optional
+	Annotations KeyChanges `json:"annotations,omitempty"`
+	// Type the Secret would have, if it differs from the type of the existing Secret.
+	// +optional
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+// KeyChanges lists the keys of a map that would be added, removed or changed.
+type KeyChanges struct {
+	// +optional
+	Added []string `json:"added,omitempty"`
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+}
+
+// PushTargetStatus reports whether a push target holds the data of the secret.
+type PushTargetStatus struct {
+	// The identifying name of the target.
+	Name string `json:"name"`
+	// Whether the target held the data of the secret when last checked.
+	Synced bool `json:"synced"`
+	// Resource version of the secret whose data was last written to the target.
+	// +optional
+	SecretResourceVersion string `json:"secretResourceVersion,omitempty"`
+	// When the data was last written to the target.
+	// +optional
+	LastPushTime *metav1.Time `json:"lastPushTime,omitempty"`
+	// When the data of the target was last found to differ from the data written by the controller.
+	// +optional
+	LastDriftTime *metav1.Time `json:"lastDriftTime,omitempty"`
+	// Error encountered when last checking or writing the target.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
@@ -0,0 +1,91 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretTemplatePolicy restricts the input resources SecretTemplates in the selected namespaces can read, and the
+// sources they can read them from.
+// SecretTemplates violating a policy fail to reconcile until either the SecretTemplate or the policy changes.
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name=Age,JSONPath=.metadata.creationTimestamp,description=Time since creation,type=date
+type SecretTemplatePolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretTemplatePolicySpec `json:"spec"`
+}
+
+// SecretTemplatePolicyList is a list of SecretTemplatePolicies
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SecretTemplatePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SecretTemplatePolicy `json:"items"`
+}
+
+// SecretTemplatePolicySpec contains spec information
+type SecretTemplatePolicySpec struct {
+	// Selects the namespaces of the SecretTemplates the policy applies to. If not specified, the policy applies to all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Input resources read from the API server SecretTemplates are allowed to read. If specified, every such input resource
+	// must match at least one of the rules.
+	// +optional
+	Allow []InputResourceRule `json:"allow,omitempty"`
+
+	// Input resources read from the API server SecretTemplates are not allowed to read, even if they are allowed by a rule.
+	// +optional
+	Deny []InputResourceRule `json:"deny,omitempty"`
+
+	// Sources other than the API server SecretTemplates are allowed to read input resources from. If not specified,
+	// all input resources must be read from the API server, and are subject to allow and deny.
+	// +optional
+	AllowedSources []InputSource `json:"allowedSources,omitempty"`
+
+	// The maximum number of input resources of a SecretTemplate, regardless of where they are read from.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxInputResources *int32 `json:"maxInputResources,omitempty"`
+}
+
+// InputSource is a source of input resources other than the API server.
+// +kubebuilder:validation:Enum=file;vault;external;serviceAccountToken
+type InputSource string
+
+// InputResourceRule matches input resources read from the API server. An input resource matches a rule if it matches
+// all of its specified fields, a rule without fields matches all input resources.
+type InputResourceRule struct {
+	// API groups of the input resources in any version, "" being the core group. "*" matches all groups.
+	// +optional
+	APIGroups []string `json:"apiGroups,omitempty"`
+
+	// Kinds of the input resources. "*" matches all kinds.
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Namespaces of the input resources, which are always read from the namespace of the SecretTemplate.
+	// Patterns like "team-*" are supported.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Names of the input resources. Patterns like "db-*" are supported. Input resources selected by labels are
+	// matched by the names of the resources listed.
+	// +optional
+	Names []string `json:"names,omitempty"`
+}
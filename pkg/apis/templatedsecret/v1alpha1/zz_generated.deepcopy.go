@@ -12,6 +12,7 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -31,6 +32,77 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataFromSource) DeepCopyInto(out *DataFromSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataFromSource.
+func (in *DataFromSource) DeepCopy() *DataFromSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DataFromSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptedOutput) DeepCopyInto(out *EncryptedOutput) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptedOutput.
+func (in *EncryptedOutput) DeepCopy() *EncryptedOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptedOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalInputSource) DeepCopyInto(out *ExternalInputSource) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalInputSource.
+func (in *ExternalInputSource) DeepCopy() *ExternalInputSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalInputSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileInputSource) DeepCopyInto(out *FileInputSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileInputSource.
+func (in *FileInputSource) DeepCopy() *FileInputSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FileInputSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GenericStatus) DeepCopyInto(out *GenericStatus) {
 	*out = *in
@@ -52,10 +124,46 @@ func (in *GenericStatus) DeepCopy() *GenericStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPPushTarget) DeepCopyInto(out *HTTPPushTarget) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPPushTarget.
+func (in *HTTPPushTarget) DeepCopy() *HTTPPushTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPPushTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InputResource) DeepCopyInto(out *InputResource) {
 	*out = *in
-	out.Ref = in.Ref
+	in.Ref.DeepCopyInto(&out.Ref)
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileInputSource)
+		**out = **in
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultInputSource)
+		**out = **in
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalInputSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountToken != nil {
+		in, out := &in.ServiceAccountToken, &out.ServiceAccountToken
+		*out = new(ServiceAccountTokenInputSource)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -72,6 +180,11 @@ func (in *InputResource) DeepCopy() *InputResource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InputResourceRef) DeepCopyInto(out *InputResourceRef) {
 	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -85,6 +198,63 @@ func (in *InputResourceRef) DeepCopy() *InputResourceRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputResourceRule) DeepCopyInto(out *InputResourceRule) {
+	*out = *in
+	if in.APIGroups != nil {
+		in, out := &in.APIGroups, &out.APIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputResourceRule.
+func (in *InputResourceRule) DeepCopy() *InputResourceRule {
+	if in == nil {
+		return nil
+	}
+	out := new(InputResourceRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JSONPathRead) DeepCopyInto(out *JSONPathRead) {
+	*out = *in
+	if in.Functions != nil {
+		in, out := &in.Functions, &out.Functions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JSONPathRead.
+func (in *JSONPathRead) DeepCopy() *JSONPathRead {
+	if in == nil {
+		return nil
+	}
+	out := new(JSONPathRead)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JSONPathTemplate) DeepCopyInto(out *JSONPathTemplate) {
 	*out = *in
@@ -102,6 +272,32 @@ func (in *JSONPathTemplate) DeepCopyInto(out *JSONPathTemplate) {
 			(*out)[key] = val
 		}
 	}
+	if in.DataFrom != nil {
+		in, out := &in.DataFrom, &out.DataFrom
+		*out = make([]DataFromSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.URIs != nil {
+		in, out := &in.URIs, &out.URIs
+		*out = make(map[string]URITemplate, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.JWTs != nil {
+		in, out := &in.JWTs, &out.JWTs
+		*out = make(map[string]JWTTemplate, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Kubeconfigs != nil {
+		in, out := &in.Kubeconfigs, &out.Kubeconfigs
+		*out = make(map[string]KubeconfigTemplate, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	in.Metadata.DeepCopyInto(&out.Metadata)
 	return
 }
@@ -116,6 +312,209 @@ func (in *JSONPathTemplate) DeepCopy() *JSONPathTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JWTTemplate) DeepCopyInto(out *JWTTemplate) {
+	*out = *in
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Lifetime != nil {
+		in, out := &in.Lifetime, &out.Lifetime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JWTTemplate.
+func (in *JWTTemplate) DeepCopy() *JWTTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(JWTTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyChanges) DeepCopyInto(out *KeyChanges) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changed != nil {
+		in, out := &in.Changed, &out.Changed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyChanges.
+func (in *KeyChanges) DeepCopy() *KeyChanges {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyChanges)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyExplanation) DeepCopyInto(out *KeyExplanation) {
+	*out = *in
+	if in.Reads != nil {
+		in, out := &in.Reads, &out.Reads
+		*out = make([]JSONPathRead, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Length != nil {
+		in, out := &in.Length, &out.Length
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyExplanation.
+func (in *KeyExplanation) DeepCopy() *KeyExplanation {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyExplanation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeconfigServiceAccountToken) DeepCopyInto(out *KubeconfigServiceAccountToken) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeconfigServiceAccountToken.
+func (in *KubeconfigServiceAccountToken) DeepCopy() *KubeconfigServiceAccountToken {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeconfigServiceAccountToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeconfigTemplate) DeepCopyInto(out *KubeconfigTemplate) {
+	*out = *in
+	if in.ServiceAccountToken != nil {
+		in, out := &in.ServiceAccountToken, &out.ServiceAccountToken
+		*out = new(KubeconfigServiceAccountToken)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeconfigTemplate.
+func (in *KubeconfigTemplate) DeepCopy() *KubeconfigTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeconfigTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PushTarget) DeepCopyInto(out *PushTarget) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultPushTarget)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPPushTarget)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PushTarget.
+func (in *PushTarget) DeepCopy() *PushTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PushTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PushTargetStatus) DeepCopyInto(out *PushTargetStatus) {
+	*out = *in
+	if in.LastPushTime != nil {
+		in, out := &in.LastPushTime, &out.LastPushTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastDriftTime != nil {
+		in, out := &in.LastDriftTime, &out.LastDriftTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PushTargetStatus.
+func (in *PushTargetStatus) DeepCopy() *PushTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PushTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretPreview) DeepCopyInto(out *SecretPreview) {
+	*out = *in
+	in.Data.DeepCopyInto(&out.Data)
+	in.Labels.DeepCopyInto(&out.Labels)
+	in.Annotations.DeepCopyInto(&out.Annotations)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretPreview.
+func (in *SecretPreview) DeepCopy() *SecretPreview {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretPreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretTemplate) DeepCopyInto(out *SecretTemplate) {
 	*out = *in
@@ -215,19 +614,169 @@ func (in *SecretTemplateMetadata) DeepCopy() *SecretTemplateMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTemplatePolicy) DeepCopyInto(out *SecretTemplatePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTemplatePolicy.
+func (in *SecretTemplatePolicy) DeepCopy() *SecretTemplatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTemplatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretTemplatePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTemplatePolicyList) DeepCopyInto(out *SecretTemplatePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SecretTemplatePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTemplatePolicyList.
+func (in *SecretTemplatePolicyList) DeepCopy() *SecretTemplatePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTemplatePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretTemplatePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTemplatePolicySpec) DeepCopyInto(out *SecretTemplatePolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]InputResourceRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Deny != nil {
+		in, out := &in.Deny, &out.Deny
+		*out = make([]InputResourceRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowedSources != nil {
+		in, out := &in.AllowedSources, &out.AllowedSources
+		*out = make([]InputSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxInputResources != nil {
+		in, out := &in.MaxInputResources, &out.MaxInputResources
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTemplatePolicySpec.
+func (in *SecretTemplatePolicySpec) DeepCopy() *SecretTemplatePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTemplatePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTemplateServiceAccount) DeepCopyInto(out *SecretTemplateServiceAccount) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTemplateServiceAccount.
+func (in *SecretTemplateServiceAccount) DeepCopy() *SecretTemplateServiceAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTemplateServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretTemplateSpec) DeepCopyInto(out *SecretTemplateSpec) {
 	*out = *in
 	if in.InputResources != nil {
 		in, out := &in.InputResources, &out.InputResources
 		*out = make([]InputResource, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.JSONPathTemplate != nil {
 		in, out := &in.JSONPathTemplate, &out.JSONPathTemplate
 		*out = new(JSONPathTemplate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ServiceAccount != nil {
+		in, out := &in.ServiceAccount, &out.ServiceAccount
+		*out = new(SecretTemplateServiceAccount)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Push != nil {
+		in, out := &in.Push, &out.Push
+		*out = make([]PushTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EncryptedOutput != nil {
+		in, out := &in.EncryptedOutput, &out.EncryptedOutput
+		*out = new(EncryptedOutput)
+		**out = **in
+	}
 	return
 }
 
@@ -246,6 +795,30 @@ func (in *SecretTemplateStatus) DeepCopyInto(out *SecretTemplateStatus) {
 	*out = *in
 	out.Secret = in.Secret
 	in.GenericStatus.DeepCopyInto(&out.GenericStatus)
+	if in.AbsentInputResources != nil {
+		in, out := &in.AbsentInputResources, &out.AbsentInputResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Push != nil {
+		in, out := &in.Push, &out.Push
+		*out = make([]PushTargetStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Preview != nil {
+		in, out := &in.Preview, &out.Preview
+		*out = new(SecretPreview)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Explanation != nil {
+		in, out := &in.Explanation, &out.Explanation
+		*out = make([]KeyExplanation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -258,3 +831,84 @@ func (in *SecretTemplateStatus) DeepCopy() *SecretTemplateStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountTokenInputSource) DeepCopyInto(out *ServiceAccountTokenInputSource) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountTokenInputSource.
+func (in *ServiceAccountTokenInputSource) DeepCopy() *ServiceAccountTokenInputSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountTokenInputSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *URITemplate) DeepCopyInto(out *URITemplate) {
+	*out = *in
+	if in.Query != nil {
+		in, out := &in.Query, &out.Query
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new URITemplate.
+func (in *URITemplate) DeepCopy() *URITemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(URITemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultInputSource) DeepCopyInto(out *VaultInputSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultInputSource.
+func (in *VaultInputSource) DeepCopy() *VaultInputSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultInputSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultPushTarget) DeepCopyInto(out *VaultPushTarget) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultPushTarget.
+func (in *VaultPushTarget) DeepCopy() *VaultPushTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultPushTarget)
+	in.DeepCopyInto(out)
+	return out
+}
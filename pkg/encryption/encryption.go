@@ -0,0 +1,90 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package encryption encrypts payloads to age or OpenPGP recipients, producing ASCII armored ciphertext
+// that can be stored in ConfigMaps or committed to Git.
+package encryption
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	agearmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// Supported encryption formats.
+const (
+	FormatAge = "age"
+	FormatPGP = "pgp"
+)
+
+// Encrypt encrypts plaintext to all recipients. For the age format recipients are age public keys, one per line;
+// lines starting with "#" are ignored. For the pgp format recipients are one or more ASCII armored OpenPGP public keys.
+func Encrypt(format, recipients string, plaintext []byte) ([]byte, error) {
+	switch format {
+	case FormatAge:
+		return encryptAge(recipients, plaintext)
+	case FormatPGP:
+		return encryptPGP(recipients, plaintext)
+	default:
+		return nil, fmt.Errorf("unsupported encryption format %q, must be %s or %s", format, FormatAge, FormatPGP)
+	}
+}
+
+func encryptAge(recipients string, plaintext []byte) ([]byte, error) {
+	parsed, err := age.ParseRecipients(strings.NewReader(recipients))
+	if err != nil {
+		return nil, fmt.Errorf("parsing age recipients: %w", err)
+	}
+
+	var out bytes.Buffer
+	armored := agearmor.NewWriter(&out)
+	encrypted, err := age.Encrypt(armored, parsed...)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(encrypted, plaintext, armored); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func encryptPGP(recipients string, plaintext []byte) ([]byte, error) {
+	keyRing, err := openpgp.ReadArmoredKeyRing(strings.NewReader(recipients))
+	if err != nil {
+		return nil, fmt.Errorf("parsing pgp recipients: %w", err)
+	}
+	if len(keyRing) == 0 {
+		return nil, fmt.Errorf("parsing pgp recipients: no keys found")
+	}
+
+	var out bytes.Buffer
+	armored, err := pgparmor.Encode(&out, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := openpgp.Encrypt(armored, keyRing, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(encrypted, plaintext, armored); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// write writes plaintext to encrypted and closes it, followed by the armor it writes to.
+func write(encrypted io.WriteCloser, plaintext []byte, armored io.Closer) error {
+	if _, err := encrypted.Write(plaintext); err != nil {
+		return err
+	}
+	if err := encrypted.Close(); err != nil {
+		return err
+	}
+	return armored.Close()
+}
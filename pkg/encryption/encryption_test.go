@@ -0,0 +1,74 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	agearmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/drae/templated-secret-controller/pkg/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Encrypt_Age(t *testing.T) {
+	alice, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	bob, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	recipients := "# alice\n" + alice.Recipient().String() + "\n\n# bob\n" + bob.Recipient().String() + "\n"
+	ciphertext, err := encryption.Encrypt(encryption.FormatAge, recipients, []byte("p@ss"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ciphertext), "-----BEGIN AGE ENCRYPTED FILE-----"))
+
+	for _, identity := range []*age.X25519Identity{alice, bob} {
+		decrypted, err := age.Decrypt(agearmor.NewReader(bytes.NewReader(ciphertext)), identity)
+		require.NoError(t, err)
+		plaintext, err := io.ReadAll(decrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "p@ss", string(plaintext))
+	}
+
+	_, err = encryption.Encrypt(encryption.FormatAge, "not-a-key", []byte("p@ss"))
+	assert.ErrorContains(t, err, "parsing age recipients: ")
+}
+
+func Test_Encrypt_PGP(t *testing.T) {
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	require.NoError(t, err)
+
+	var publicKey bytes.Buffer
+	armored, err := pgparmor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(armored))
+	require.NoError(t, armored.Close())
+
+	ciphertext, err := encryption.Encrypt(encryption.FormatPGP, publicKey.String(), []byte("p@ss"))
+	require.NoError(t, err)
+
+	block, err := pgparmor.Decode(bytes.NewReader(ciphertext))
+	require.NoError(t, err)
+	assert.Equal(t, "PGP MESSAGE", block.Type)
+
+	message, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{entity}, nil, nil)
+	require.NoError(t, err)
+	plaintext, err := io.ReadAll(message.UnverifiedBody)
+	require.NoError(t, err)
+	assert.Equal(t, "p@ss", string(plaintext))
+
+	_, err = encryption.Encrypt(encryption.FormatPGP, "not-a-key", []byte("p@ss"))
+	assert.ErrorContains(t, err, "parsing pgp recipients: ")
+}
+
+func Test_Encrypt_UnsupportedFormat(t *testing.T) {
+	_, err := encryption.Encrypt("sops", "", []byte("p@ss"))
+	assert.EqualError(t, err, `unsupported encryption format "sops", must be age or pgp`)
+}
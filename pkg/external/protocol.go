@@ -0,0 +1,47 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package external resolves input resources using providers running alongside the controller, e.g. as sidecars.
+//
+// Providers serve HTTP on a Unix socket named after the provider. The controller sends a ResolveRequest as JSON
+// in a POST request to ResolvePath and expects a ResolveResponse as JSON. Providers respond with 404 Not Found
+// when the requested input does not exist, and with an ErrorResponse and any other non 2xx status on failure.
+package external
+
+// ResolvePath is the path providers serve resolve requests on.
+const ResolvePath = "/v1/resolve"
+
+// Audience returns the audience of the Service Account tokens passed to a provider, which the provider should require
+// when reviewing them. Tokens are scoped to a single provider, so that they can not be used to authenticate to the
+// API server or to other providers.
+func Audience(provider string) string {
+	return "templatedsecret.starstreak.dev/external-provider/" + provider
+}
+
+// ResolveRequest asks a provider to resolve an input resource of a SecretTemplate.
+type ResolveRequest struct {
+	// Namespace of the SecretTemplate.
+	Namespace string `json:"namespace"`
+	// Name of the SecretTemplate.
+	Template string `json:"template"`
+	// Name of the input resource.
+	Input string `json:"input"`
+	// Provider specific configuration of the input resource.
+	Config map[string]string `json:"config,omitempty"`
+	// Token of the SecretTemplate's Service Account with the audience returned by Audience, allowing the provider to
+	// authorize the request or to authenticate with its backend. Empty if the SecretTemplate does not specify a Service Account.
+	ServiceAccountToken string `json:"serviceAccountToken,omitempty"`
+}
+
+// ResolveResponse carries the data of a resolved input resource.
+type ResolveResponse struct {
+	// Data is available to templates under the name of the input resource.
+	Data map[string]string `json:"data"`
+	// TTLSeconds is how long the data is valid for. The controller resolves the input resource again once it has passed.
+	TTLSeconds int64 `json:"ttlSeconds,omitempty"`
+}
+
+// ErrorResponse describes why a provider failed to resolve an input resource.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
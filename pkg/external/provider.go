@@ -0,0 +1,171 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	dialTimeout    = 5 * time.Second
+)
+
+// Provider names are used as socket file names.
+var providerName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Config configures how providers are reached.
+type Config struct {
+	// Directory containing the Unix sockets of providers, named <provider>.sock.
+	SocketDir string
+	// Timeout of a single resolve request. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Lifetime of the Service Account tokens passed to providers, unless set by the SecretTemplate's Service Account.
+	// Tokens are always scoped to the provider with Audience, so their audiences are not configurable.
+	Tokens generator.TokenSettings
+}
+
+// Provider is an InputProvider delegating to external providers. Provider is thread-safe.
+type Provider struct {
+	config       Config
+	tokenManager generator.TokenManager
+
+	// Holds a HTTP client, and with it a pool of connections, per provider
+	clients map[string]*http.Client
+	mu      sync.Mutex
+}
+
+var _ generator.InputProvider = &Provider{}
+
+// NewProvider creates a new Provider. Service Account tokens passed to providers are requested from tokenManager.
+func NewProvider(config Config, tokenManager generator.TokenManager) *Provider {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &Provider{
+		config:       config,
+		tokenManager: tokenManager,
+		clients:      map[string]*http.Client{},
+	}
+}
+
+// Resolve asks the provider an input resource refers to for its data.
+func (p *Provider) Resolve(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, input tsv1alpha1.InputResource) (generator.ProvidedInput, error) {
+	source := input.External
+	if source == nil {
+		return generator.ProvidedInput{}, fmt.Errorf("input resource %s does not refer to an external provider", input.Name)
+	}
+	if !providerName.MatchString(source.Provider) {
+		return generator.ProvidedInput{}, fmt.Errorf("provider %q is not a valid provider name", source.Provider)
+	}
+
+	request := ResolveRequest{
+		Namespace: secretTemplate.Namespace,
+		Template:  secretTemplate.Name,
+		Input:     input.Name,
+		Config:    source.Config,
+	}
+
+	if serviceAccount := secretTemplate.Spec.GetServiceAccount(); serviceAccount != nil {
+		tokenRequest := p.config.Tokens.TokenRequest(*serviceAccount)
+		tokenRequest.Spec.Audiences = []string{Audience(source.Provider)}
+		tokenRequest, err := p.tokenManager.GetServiceAccountToken(ctx, secretTemplate.Namespace, serviceAccount.Name, tokenRequest)
+		if err != nil {
+			return generator.ProvidedInput{}, fmt.Errorf("requesting service account token: %w", err)
+		}
+		request.ServiceAccountToken = tokenRequest.Status.Token
+	}
+
+	response, err := p.resolve(ctx, source.Provider, request)
+	if err != nil {
+		return generator.ProvidedInput{}, fmt.Errorf("provider %s: %w", source.Provider, err)
+	}
+
+	values := make(map[string]interface{}, len(response.Data))
+	for key, value := range response.Data {
+		values[key] = value
+	}
+
+	return generator.ProvidedInput{
+		Values: values,
+		TTL:    time.Duration(response.TTLSeconds) * time.Second,
+	}, nil
+}
+
+func (p *Provider) resolve(ctx context.Context, provider string, request ResolveRequest) (ResolveResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return ResolveResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	// The host is ignored as connections are made to the provider's socket.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+provider+ResolvePath, bytes.NewReader(body))
+	if err != nil {
+		return ResolveResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client(provider).Do(req)
+	if err != nil {
+		return ResolveResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ResolveResponse{}, generator.ErrInputNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error != "" {
+			return ResolveResponse{}, fmt.Errorf("responded with %d: %s", resp.StatusCode, errResp.Error)
+		}
+		return ResolveResponse{}, fmt.Errorf("responded with %d", resp.StatusCode)
+	}
+
+	var response ResolveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return ResolveResponse{}, fmt.Errorf("decoding response: %w", err)
+	}
+	return response, nil
+}
+
+// client returns the HTTP client connecting to the socket of provider.
+func (p *Provider) client(provider string) *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, found := p.clients[provider]; found {
+		return client
+	}
+
+	socket := filepath.Join(p.config.SocketDir, provider+".sock")
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	p.clients[provider] = client
+	return client
+}
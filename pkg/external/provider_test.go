@@ -0,0 +1,157 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package external_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/external"
+	"github.com/drae/templated-secret-controller/pkg/external/static"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Provider_Resolve(t *testing.T) {
+	socketDir := t.TempDir()
+	secretsFile := filepath.Join(t.TempDir(), "secrets.json")
+	require.NoError(t, os.WriteFile(secretsFile, []byte(`{"db": {"password": "p@ss", "username": "admin"}}`), 0o600))
+
+	recorder := &recordingResolver{Resolver: static.Resolver{File: secretsFile, TTL: 5 * time.Minute}}
+	serve(t, filepath.Join(socketDir, "static.sock"), recorder)
+	serve(t, filepath.Join(socketDir, "slow.sock"), slowResolver{})
+
+	provider := external.NewProvider(external.Config{
+		SocketDir: socketDir,
+		Timeout:   200 * time.Millisecond,
+		Tokens:    generator.TokenSettings{Expiration: 2 * time.Hour, Audiences: []string{"https://kubernetes.default.svc"}},
+	}, fakeTokenManager{})
+
+	t.Run("resolves data and ttl", func(t *testing.T) {
+		provided, err := provider.Resolve(context.Background(), secretTemplate("reader"), input("static", map[string]string{"secret": "db"}))
+		require.NoError(t, err)
+		assert.Equal(t, generator.ProvidedInput{
+			Values: map[string]interface{}{"password": "p@ss", "username": "admin"},
+			TTL:    5 * time.Minute,
+		}, provided)
+
+		assert.Equal(t, external.ResolveRequest{
+			Namespace:           "test",
+			Template:            "secretTemplate",
+			Input:               "creds",
+			Config:              map[string]string{"secret": "db"},
+			ServiceAccountToken: "jwt-test-reader-templatedsecret.starstreak.dev/external-provider/static-7200",
+		}, recorder.last)
+	})
+
+	t.Run("passes a token scoped to the provider with the lifetime of the service account", func(t *testing.T) {
+		expiration := int64(600)
+		secretTemplate := secretTemplate("")
+		secretTemplate.Spec.ServiceAccount = &tsv1alpha1.SecretTemplateServiceAccount{Name: "reader", Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &expiration}
+
+		_, err := provider.Resolve(context.Background(), secretTemplate, input("static", map[string]string{"secret": "db"}))
+		require.NoError(t, err)
+		assert.Equal(t, "jwt-test-reader-templatedsecret.starstreak.dev/external-provider/static-600", recorder.last.ServiceAccountToken)
+	})
+
+	t.Run("does not pass a token without a service account", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate(""), input("static", map[string]string{"secret": "db"}))
+		require.NoError(t, err)
+		assert.Empty(t, recorder.last.ServiceAccountToken)
+	})
+
+	t.Run("missing inputs are reported as not found", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), input("static", map[string]string{"secret": "missing"}))
+		assert.ErrorIs(t, err, generator.ErrInputNotFound)
+		assert.EqualError(t, err, "provider static: input not found")
+	})
+
+	t.Run("provider errors", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), input("static", nil))
+		assert.EqualError(t, err, "provider static: responded with 500: config key secret must be set")
+	})
+
+	t.Run("provider timing out", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), input("slow", nil))
+		assert.ErrorContains(t, err, "provider slow: ")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("provider not running", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), input("missing", nil))
+		assert.ErrorContains(t, err, "missing.sock")
+		assert.NotErrorIs(t, err, generator.ErrInputNotFound)
+	})
+
+	t.Run("invalid provider name", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), input("../static", nil))
+		assert.EqualError(t, err, `provider "../static" is not a valid provider name`)
+	})
+}
+
+func serve(t *testing.T, socket string, resolver external.Resolver) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- external.Serve(ctx, socket, resolver) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(socket)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "provider did not start serving")
+}
+
+func secretTemplate(serviceAccount string) *tsv1alpha1.SecretTemplate {
+	return &tsv1alpha1.SecretTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "secretTemplate", Namespace: "test"},
+		Spec:       tsv1alpha1.SecretTemplateSpec{ServiceAccountName: serviceAccount},
+	}
+}
+
+func input(provider string, config map[string]string) tsv1alpha1.InputResource {
+	return tsv1alpha1.InputResource{
+		Name:     "creds",
+		External: &tsv1alpha1.ExternalInputSource{Provider: provider, Config: config},
+	}
+}
+
+type recordingResolver struct {
+	external.Resolver
+	last external.ResolveRequest
+}
+
+func (r *recordingResolver) Resolve(ctx context.Context, request external.ResolveRequest) (external.ResolveResponse, error) {
+	r.last = request
+	return r.Resolver.Resolve(ctx, request)
+}
+
+type slowResolver struct{}
+
+func (slowResolver) Resolve(ctx context.Context, _ external.ResolveRequest) (external.ResolveResponse, error) {
+	select {
+	case <-ctx.Done():
+		return external.ResolveResponse{}, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return external.ResolveResponse{}, nil
+	}
+}
+
+type fakeTokenManager struct{}
+
+func (fakeTokenManager) GetServiceAccountToken(_ context.Context, namespace, name string, tr *authv1.TokenRequest) (*authv1.TokenRequest, error) {
+	tr.Status.Token = fmt.Sprintf("jwt-%s-%s-%s-%d", namespace, name, strings.Join(tr.Spec.Audiences, ","), *tr.Spec.ExpirationSeconds)
+	return tr, nil
+}
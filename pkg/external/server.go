@@ -0,0 +1,87 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrNotFound is returned by a Resolver when the requested input does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Resolver is implemented by providers to resolve input resources.
+type Resolver interface {
+	Resolve(ctx context.Context, request ResolveRequest) (ResolveResponse, error)
+}
+
+// Handler serves resolve requests using resolver.
+func Handler(resolver Resolver) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ResolvePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respond(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed"})
+			return
+		}
+
+		var request ResolveRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			respond(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("decoding request: %s", err)})
+			return
+		}
+
+		response, err := resolver.Resolve(r.Context(), request)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			respond(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			respond(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		default:
+			respond(w, http.StatusOK, response)
+		}
+	})
+	return mux
+}
+
+// Serve serves resolve requests using resolver on the Unix socket at socketPath until ctx is done.
+// A socket left behind at socketPath, e.g. by a previous run of the provider, is replaced.
+func Serve(ctx context.Context, socketPath string, resolver Resolver) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           Handler(resolver),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package static is a reference implementation of an external provider. It serves secrets from a JSON file
+// mapping secret names to their data, e.g. {"db": {"password": "secret"}}. Input resources select a secret
+// using the "secret" config key. The file is read on every request so that it can be updated in place.
+package static
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/drae/templated-secret-controller/pkg/external"
+)
+
+// Resolver resolves input resources from the secrets in a JSON file.
+type Resolver struct {
+	// Path of the JSON file.
+	File string
+	// TTL returned with every secret.
+	TTL time.Duration
+}
+
+var _ external.Resolver = Resolver{}
+
+// Resolve returns the secret selected by the "secret" config key of the input resource.
+func (r Resolver) Resolve(_ context.Context, request external.ResolveRequest) (external.ResolveResponse, error) {
+	name := request.Config["secret"]
+	if name == "" {
+		return external.ResolveResponse{}, fmt.Errorf("config key secret must be set")
+	}
+
+	content, err := os.ReadFile(r.File)
+	if err != nil {
+		return external.ResolveResponse{}, err
+	}
+
+	var secrets map[string]map[string]string
+	if err := json.Unmarshal(content, &secrets); err != nil {
+		return external.ResolveResponse{}, fmt.Errorf("parsing %s: %w", r.File, err)
+	}
+
+	data, found := secrets[name]
+	if !found {
+		return external.ResolveResponse{}, fmt.Errorf("secret %s: %w", name, external.ErrNotFound)
+	}
+
+	return external.ResolveResponse{Data: data, TTLSeconds: int64(r.TTL.Seconds())}, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fileinput allows input resources to be read from files mounted into the controller.
+// Files can only be read from a single allow-listed directory, within the subdirectory named after the namespace of
+// the resource reading them, so that tenants can not read each other's files. Files are watched for changes, and all
+// resources that read a changed file are notified.
+package fileinput
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// Watcher reads files from the directories of namespaces within an allow-listed directory and notifies resources
+// tracking them when they change.
+// Watcher is thread-safe.
+type Watcher struct {
+	dir     string
+	watcher *fsnotify.Watcher
+	events  chan event.GenericEvent
+	log     logr.Logger
+
+	// Holds a set of resources(tracking) to set of files(tracked), along with whether the directory of a file is watched
+	tracker map[types.NamespacedName]map[string]bool
+	// Holds the number of tracked files within each watched directory
+	watched map[string]int
+	mu      sync.Mutex
+}
+
+// NewWatcher creates a new Watcher allowing files within dir/<namespace> to be read by resources in that namespace.
+func NewWatcher(dir string, log logr.Logger) (*Watcher, error) {
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving file input directory: %w", err)
+	}
+	resolvedDir, err = filepath.Abs(resolvedDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving file input directory: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating file watcher: %w", err)
+	}
+
+	return &Watcher{
+		dir:     resolvedDir,
+		watcher: watcher,
+		events:  make(chan event.GenericEvent),
+		log:     log,
+		tracker: map[types.NamespacedName]map[string]bool{},
+		watched: map[string]int{},
+	}, nil
+}
+
+// Read returns the content of the file at path for a resource in namespace. Relative paths are resolved against the
+// directory of the namespace within the allow-listed directory. Reading files outside the directory of the namespace,
+// including through symlinks, is an error.
+func (w *Watcher) Read(namespace, path string) ([]byte, error) {
+	file, err := w.resolve(namespace, path)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return nil, err
+	}
+	if !w.contains(namespace, resolved) {
+		return nil, fmt.Errorf("file %s is outside of the file input directory of namespace %s", path, namespace)
+	}
+
+	return os.ReadFile(resolved)
+}
+
+// Track records that the tracking resource reads the files at paths within the directory of its namespace, replacing
+// previously tracked files. Files are tracked even if they do not exist yet.
+func (w *Watcher) Track(tracking types.NamespacedName, paths ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.untrackAll(tracking)
+
+	set := map[string]bool{}
+	for _, path := range paths {
+		file, err := w.resolve(tracking.Namespace, path)
+		if err != nil {
+			continue
+		}
+		if _, found := set[file]; found {
+			continue
+		}
+		set[file] = w.watch(filepath.Dir(file))
+	}
+
+	if len(set) > 0 {
+		w.tracker[tracking] = set
+	}
+}
+
+// UntrackAll untracks all files of the tracking resource. This method is idempotent
+func (w *Watcher) UntrackAll(tracking types.NamespacedName) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.untrackAll(tracking)
+}
+
+// Events returns the channel on which resources tracking a changed file are sent.
+func (w *Watcher) Events() <-chan event.GenericEvent {
+	return w.events
+}
+
+// Start forwards file changes until ctx is done. It implements manager.Runnable.
+func (w *Watcher) Start(ctx context.Context) error {
+	defer w.watcher.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.log.Error(err, "Watching file inputs")
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			// Files mounted from volumes are usually updated by swapping a symlink or renaming a file in their directory,
+			// so every change within a directory is considered a change of all files tracked in it.
+			for _, tracking := range w.trackingDir(filepath.Dir(ev.Name)) {
+				select {
+				case w.events <- event.GenericEvent{Object: &metav1.PartialObjectMetadata{
+					ObjectMeta: metav1.ObjectMeta{Namespace: tracking.Namespace, Name: tracking.Name},
+				}}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// resolve returns the cleaned absolute path of a file within the directory of a namespace, without following symlinks.
+func (w *Watcher) resolve(namespace, path string) (string, error) {
+	if namespace == "" || strings.ContainsRune(namespace, filepath.Separator) || namespace == "." || namespace == ".." {
+		return "", fmt.Errorf("namespace %q has no file input directory", namespace)
+	}
+
+	file := path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(w.namespaceDir(namespace), file)
+	}
+	file = filepath.Clean(file)
+
+	if !w.contains(namespace, file) {
+		return "", fmt.Errorf("file %s is outside of the file input directory of namespace %s", path, namespace)
+	}
+	return file, nil
+}
+
+func (w *Watcher) namespaceDir(namespace string) string {
+	return filepath.Join(w.dir, namespace)
+}
+
+func (w *Watcher) contains(namespace, file string) bool {
+	return strings.HasPrefix(file, w.namespaceDir(namespace)+string(filepath.Separator))
+}
+
+func (w *Watcher) trackingDir(dir string) []types.NamespacedName {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var trackingList []types.NamespacedName
+	for tracking, files := range w.tracker {
+		for file := range files {
+			if filepath.Dir(file) == dir {
+				trackingList = append(trackingList, tracking)
+				break
+			}
+		}
+	}
+	return trackingList
+}
+
+func (w *Watcher) watch(dir string) bool {
+	if w.watched[dir] == 0 {
+		if err := w.watcher.Add(dir); err != nil {
+			// The directory may not exist yet. It is watched again once the resource tracking it is reconciled.
+			w.log.Info("Unable to watch file input directory", "directory", dir, "error", err.Error())
+			return false
+		}
+	}
+	w.watched[dir]++
+	return true
+}
+
+func (w *Watcher) untrackAll(tracking types.NamespacedName) {
+	for file, watched := range w.tracker[tracking] {
+		if !watched {
+			continue
+		}
+		dir := filepath.Dir(file)
+		w.watched[dir]--
+		if w.watched[dir] == 0 {
+			delete(w.watched, dir)
+			_ = w.watcher.Remove(dir)
+		}
+	}
+	delete(w.tracker, tracking)
+}
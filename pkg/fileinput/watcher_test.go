@@ -0,0 +1,86 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fileinput_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drae/templated-secret-controller/pkg/fileinput"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_Watcher_Read(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "test", "vault"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "other"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "vault", "token"), []byte("s.token"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other", "token"), []byte("s.other"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "root"), []byte("root"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "root"), filepath.Join(dir, "test", "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "other", "token"), filepath.Join(dir, "test", "other")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "test", "vault", "token"), filepath.Join(dir, "test", "link")))
+
+	watcher, err := fileinput.NewWatcher(dir, logr.Discard())
+	require.NoError(t, err)
+
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	for _, path := range []string{"vault/token", filepath.Join(resolvedDir, "test", "vault", "token"), "link"} {
+		content, err := watcher.Read("test", path)
+		require.NoError(t, err, path)
+		assert.Equal(t, "s.token", string(content), path)
+	}
+
+	// Files of other namespaces can not be read, neither by path nor through symlinks.
+	for _, path := range []string{"../other/token", filepath.Join(resolvedDir, "other", "token"), "other", "../root", filepath.Join(outside, "root"), "escape", ".", ""} {
+		_, err := watcher.Read("test", path)
+		assert.ErrorContains(t, err, "is outside of the file input directory of namespace test", path)
+	}
+
+	for _, namespace := range []string{"", ".."} {
+		_, err := watcher.Read(namespace, "token")
+		assert.ErrorContains(t, err, "has no file input directory", namespace)
+	}
+
+	_, err = watcher.Read("test", "missing")
+	assert.True(t, os.IsNotExist(err), "expected a not exist error, got %v", err)
+}
+
+func Test_Watcher_Events(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "test", "vault"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "vault", "token"), []byte("s.token"), 0o600))
+
+	watcher, err := fileinput.NewWatcher(dir, logr.Discard())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Start(ctx)
+
+	tracking := types.NamespacedName{Namespace: "test", Name: "secretTemplate"}
+	untracked := types.NamespacedName{Namespace: "test", Name: "untracked"}
+	watcher.Track(tracking, "vault/token")
+	watcher.Track(untracked, "vault/token")
+	watcher.UntrackAll(untracked)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "vault", "token"), []byte("s.rotated"), 0o600))
+
+	select {
+	case ev := <-watcher.Events():
+		assert.Equal(t, tracking, types.NamespacedName{Namespace: ev.Object.GetNamespace(), Name: ev.Object.GetName()})
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected an event for the tracking resource")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"fmt"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// evaluateDataFrom collects the keys of all Secrets and ConfigMaps referred to by sources.
+// Later sources, and later resources within a source, take precedence.
+func evaluateDataFrom(sources []tsv1alpha1.DataFromSource, values templateValues) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	for _, source := range sources {
+		if values.absent[source.InputResource] {
+			continue
+		}
+
+		objects, found := values.objects(source.InputResource)
+		if !found {
+			return nil, fmt.Errorf("input resource %s is not defined", source.InputResource)
+		}
+
+		for _, object := range objects {
+			obj := unstructured.Unstructured{Object: object}
+			fields := dataFields[obj.GroupVersionKind()]
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("input resource %s: %s %s is neither a Secret nor a ConfigMap", source.InputResource, obj.GetKind(), obj.GetName())
+			}
+
+			for _, field := range fields {
+				keys, ok := object[field].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for key, value := range keys {
+					if strVal, ok := value.(string); ok {
+						data[source.Prefix+key] = []byte(strVal)
+					}
+				}
+			}
+		}
+	}
+
+	return data, nil
+}
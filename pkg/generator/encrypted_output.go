@@ -0,0 +1,156 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/client/clientset/versioned/scheme"
+	"github.com/drae/templated-secret-controller/pkg/encryption"
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// encryptionRecipientsAnnotation holds a digest of the recipients the encrypted copy of a Secret was encrypted to.
+const encryptionRecipientsAnnotation = "templatedsecret.starstreak.dev/encryption-recipients"
+
+// encryptedOutput is the encrypted copy of a Secret.
+type encryptedOutput struct {
+	key        string
+	ciphertext []byte
+	// Digest of the format and recipients the copy is encrypted to
+	recipients string
+}
+
+func encryptionKey(output *tsv1alpha1.EncryptedOutput) string {
+	if output.Key != "" {
+		return output.Key
+	}
+	if output.Format == encryption.FormatPGP {
+		return "secret.asc"
+	}
+	return "secret.age"
+}
+
+// encryptOutput returns the encrypted copy of secret. Encrypting the same data twice produces different ciphertexts,
+// so the copy of the existing Secret is reused unless its data or the recipients changed.
+func (r *SecretTemplateReconciler) encryptOutput(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, values templateValues,
+	secret corev1.Secret, existing *corev1.Secret) (encryptedOutput, error) {
+	output := secretTemplate.Spec.EncryptedOutput
+	if output.ConfigMapName != "" && !r.encryptedConfigMaps {
+		return encryptedOutput{}, fmt.Errorf("encrypted configmaps are not enabled")
+	}
+
+	recipients, err := evaluateString(output.Recipients, values)
+	if err != nil {
+		return encryptedOutput{}, fmt.Errorf("templating encryptedOutput recipients: %w", err)
+	}
+
+	key := encryptionKey(output)
+	data := secretData(secret)
+	if _, found := data[key]; found && output.ConfigMapName == "" {
+		return encryptedOutput{}, fmt.Errorf("encryptedOutput key %s is also defined in the secret", key)
+	}
+
+	digest := sha256.Sum256([]byte(output.Format + "\n" + recipients))
+	encrypted := encryptedOutput{key: key, recipients: hex.EncodeToString(digest[:])}
+
+	if existing != nil && existing.Annotations[encryptionRecipientsAnnotation] == encrypted.recipients && existing.Type == secret.Type {
+		existingData := secretData(*existing)
+		delete(existingData, key)
+
+		if maps.EqualFunc(existingData, data, bytes.Equal) {
+			previous, err := r.encryptedCopy(ctx, secretTemplate, key, existing)
+			if err != nil {
+				return encryptedOutput{}, err
+			}
+			if previous != nil {
+				encrypted.ciphertext = previous
+				return encrypted, nil
+			}
+		}
+	}
+
+	manifest := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]string{
+			"name":      secretTemplate.Name,
+			"namespace": secretTemplate.Namespace,
+		},
+		"data": data,
+	}
+	if secret.Type != "" {
+		manifest["type"] = secret.Type
+	}
+
+	plaintext, err := yaml.Marshal(manifest)
+	if err != nil {
+		return encryptedOutput{}, err
+	}
+
+	encrypted.ciphertext, err = encryption.Encrypt(output.Format, recipients, plaintext)
+	if err != nil {
+		return encryptedOutput{}, fmt.Errorf("encrypting secret: %w", err)
+	}
+	return encrypted, nil
+}
+
+// encryptedCopy returns the encrypted copy stored alongside the existing Secret, if any.
+func (r *SecretTemplateReconciler) encryptedCopy(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, key string, existing *corev1.Secret) ([]byte, error) {
+	if secretTemplate.Spec.EncryptedOutput.ConfigMapName == "" {
+		return existing.Data[key], nil
+	}
+
+	configMap := corev1.ConfigMap{}
+	configMapKey := types.NamespacedName{Namespace: secretTemplate.Namespace, Name: secretTemplate.Spec.EncryptedOutput.ConfigMapName}
+	var reader client.Reader = r.client
+	if r.objectSource != nil {
+		reader = r.objectSource
+	}
+	if err := reader.Get(ctx, configMapKey, &configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if value, found := configMap.Data[key]; found {
+		return []byte(value), nil
+	}
+	return nil, nil
+}
+
+// writeEncryptedConfigMap stores the encrypted copy of a Secret in the ConfigMap of a SecretTemplate.
+func (r *SecretTemplateReconciler) writeEncryptedConfigMap(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, encrypted encryptedOutput) error {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretTemplate.Spec.EncryptedOutput.ConfigMapName,
+			Namespace: secretTemplate.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.client, &configMap, func() error {
+		// Do not take over ConfigMaps created by someone else.
+		if configMap.ResourceVersion != "" && !metav1.IsControlledBy(&configMap, secretTemplate) {
+			return fmt.Errorf("configmap %s is not controlled by the secrettemplate", configMap.Name)
+		}
+		configMap.Data = map[string]string{encrypted.key: string(encrypted.ciphertext)}
+		return controllerutil.SetControllerReference(secretTemplate, &configMap, scheme.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("creating/updating encrypted configmap: %w", err)
+	}
+	return nil
+}
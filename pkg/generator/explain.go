@@ -0,0 +1,161 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ExplainAnnKey is the annotation requesting a SecretTemplate to report how each key of its Secret is resolved.
+	ExplainAnnKey = "templatedsecret.starstreak.dev/explain"
+)
+
+// explainTemplate describes how each key of the Secret is resolved from values. JSONPaths are evaluated on their own,
+// so that the reads of a key are described even if the Secret can not be rendered.
+func explainTemplate(template *tsv1alpha1.JSONPathTemplate, values templateValues) []tsv1alpha1.KeyExplanation {
+	if template == nil {
+		return nil
+	}
+
+	var explanations []tsv1alpha1.KeyExplanation
+	defined := map[string]bool{}
+	add := func(explanation tsv1alpha1.KeyExplanation) {
+		defined[explanation.Key] = true
+		explanations = append(explanations, explanation)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(template.Data)) {
+		add(tsv1alpha1.KeyExplanation{Key: key, Source: "data", Expression: template.Data[key], Reads: explainExpression("", template.Data[key], values)})
+	}
+	for _, key := range slices.Sorted(maps.Keys(template.StringData)) {
+		add(tsv1alpha1.KeyExplanation{Key: key, Source: "stringData", Expression: template.StringData[key], Reads: explainExpression("", template.StringData[key], values)})
+	}
+	for _, key := range slices.Sorted(maps.Keys(template.URIs)) {
+		uri := template.URIs[key]
+		var reads []tsv1alpha1.JSONPathRead
+		reads = append(reads, explainExpression("scheme", uri.Scheme, values)...)
+		reads = append(reads, explainExpression("username", uri.Username, values)...)
+		reads = append(reads, explainExpression("password", uri.Password, values)...)
+		reads = append(reads, explainExpression("host", uri.Host, values)...)
+		reads = append(reads, explainExpression("port", uri.Port, values)...)
+		reads = append(reads, explainExpression("path", uri.Path, values)...)
+		for _, param := range slices.Sorted(maps.Keys(uri.Query)) {
+			reads = append(reads, explainExpression(fmt.Sprintf("query[%s]", param), uri.Query[param], values)...)
+		}
+		add(tsv1alpha1.KeyExplanation{Key: key, Source: "uris", Reads: reads})
+	}
+	for _, key := range slices.Sorted(maps.Keys(template.JWTs)) {
+		jwt := template.JWTs[key]
+		var reads []tsv1alpha1.JSONPathRead
+		reads = append(reads, explainExpression("signingKey", jwt.SigningKey, values)...)
+		reads = append(reads, explainExpression("keyID", jwt.KeyID, values)...)
+		for _, claim := range slices.Sorted(maps.Keys(jwt.Claims)) {
+			reads = append(reads, explainExpression(fmt.Sprintf("claims[%s]", claim), jwt.Claims[claim], values)...)
+		}
+		add(tsv1alpha1.KeyExplanation{Key: key, Source: "jwts", Reads: reads})
+	}
+	for _, key := range slices.Sorted(maps.Keys(template.Kubeconfigs)) {
+		kubeconfig := template.Kubeconfigs[key]
+		var reads []tsv1alpha1.JSONPathRead
+		reads = append(reads, explainExpression("server", kubeconfig.Server, values)...)
+		reads = append(reads, explainExpression("certificateAuthority", kubeconfig.CertificateAuthority, values)...)
+		reads = append(reads, explainExpression("name", kubeconfig.Name, values)...)
+		reads = append(reads, explainExpression("namespace", kubeconfig.Namespace, values)...)
+		reads = append(reads, explainExpression("token", kubeconfig.Token, values)...)
+		add(tsv1alpha1.KeyExplanation{Key: key, Source: "kubeconfigs", Reads: reads})
+	}
+
+	// Keys are copied from the last source holding them, unless they are defined by any other field.
+	copiedFrom := map[string]string{}
+	for _, source := range template.DataFrom {
+		data, err := evaluateDataFrom([]tsv1alpha1.DataFromSource{source}, values)
+		if err != nil {
+			continue
+		}
+		for key := range data {
+			copiedFrom[key] = source.InputResource
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(copiedFrom)) {
+		if !defined[key] {
+			add(tsv1alpha1.KeyExplanation{Key: key, Source: "dataFrom", Input: copiedFrom[key]})
+		}
+	}
+
+	return explanations
+}
+
+// explainExpression describes the JSONPaths of an expression, including those passed as arguments to functions.
+func explainExpression(field, expr string, values templateValues) []tsv1alpha1.JSONPathRead {
+	parsed, err := parseExpression(expr)
+	if err != nil {
+		return []tsv1alpha1.JSONPathRead{{Field: field, Path: expr, Error: err.Error()}}
+	}
+	return explainParts(field, parsed, values)
+}
+
+func explainParts(field string, parsed expression, values templateValues) []tsv1alpha1.JSONPathRead {
+	var reads []tsv1alpha1.JSONPathRead
+	for _, part := range parsed {
+		if !part.segment {
+			continue
+		}
+
+		read := tsv1alpha1.JSONPathRead{
+			Field:    field,
+			Path:     part.text,
+			JSONPath: jsonPathOpen + JSONPath(part.text).ToK8sJSONPath() + jsonPathClose,
+			Decoded:  values.readsEncodedField(part.text),
+		}
+		if match := inputFieldPath.FindStringSubmatch(part.text); match != nil {
+			read.Input = strings.ReplaceAll(match[1], `\.`, ".")
+			read.InputField = strings.ReplaceAll(match[2], `\.`, ".")
+		} else if match := inputNamePath.FindStringSubmatch(part.text); match != nil {
+			read.Input = strings.ReplaceAll(match[1], `\.`, ".")
+		}
+		for _, function := range part.functions {
+			read.Functions = append(read.Functions, function.name)
+		}
+
+		source := values.inputs
+		if read.Decoded {
+			source = values.decoded
+		}
+		matches, err := evaluatePath(part.text, source)
+		if err != nil {
+			read.Error = err.Error()
+		}
+		read.Matches = len(matches)
+		reads = append(reads, read)
+
+		for _, function := range part.functions {
+			for _, i := range slices.Sorted(maps.Keys(function.argExpressions)) {
+				reads = append(reads, explainParts(field, function.argExpressions[i], values)...)
+			}
+		}
+	}
+	return reads
+}
+
+// explainLengths sets the length of each explained key to the length of its value in the rendered Secret.
+func explainLengths(explanations []tsv1alpha1.KeyExplanation, secret corev1.Secret) {
+	for i, explanation := range explanations {
+		var length int
+		if value, found := secret.StringData[explanation.Key]; found {
+			length = len(value)
+		} else if value, found := secret.Data[explanation.Key]; found {
+			length = len(value)
+		} else {
+			continue
+		}
+		explanations[i].Length = &length
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// expression is a template string made up of literal text and JSONPath segments surrounded by $( ).
+// The value a segment resolves to can be piped through functions, for example "$(.creds.data.password | b64enc)".
+type expression []expressionPart
+
+// expressionPart is either literal text or a single $( ) segment of an expression.
+type expressionPart struct {
+	// text holds the literal text, or the JSONPath of a segment.
+	text      string
+	segment   bool
+	functions []functionCall
+}
+
+// functionCall is a function applied to the value of a segment, written as "name" or "name:arg1,arg2".
+// An unquoted argument written as $( ) is itself an expression, e.g. "$(.payload.data.body | hmac_sha256:$(.key.data.key))".
+type functionCall struct {
+	name string
+	args []string
+	// argExpressions holds the arguments that are expressions by position.
+	argExpressions map[int]expression
+}
+
+// renderedPart is the result of evaluating an expressionPart.
+type renderedPart struct {
+	value string
+	// decoded is set when the value was read from a base64 encoded field and has been decoded.
+	decoded bool
+}
+
+func parseExpression(expr string) (expression, error) {
+	var parts expression
+	literalStart := 0
+
+	for i := 0; i < len(expr)-1; i++ {
+		if expr[i] != openPrefix[0] || expr[i+1] != openBracket[0] {
+			continue
+		}
+
+		end := closingBracket(expr, i+1)
+		if end < 0 {
+			// Unterminated segments are kept as literal text.
+			break
+		}
+
+		if i > literalStart {
+			parts = append(parts, expressionPart{text: expr[literalStart:i]})
+		}
+
+		part, err := parseSegment(expr[i+2 : end])
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+
+		i = end
+		literalStart = end + 1
+	}
+
+	if literalStart < len(expr) {
+		parts = append(parts, expressionPart{text: expr[literalStart:]})
+	}
+
+	return parts, nil
+}
+
+func parseSegment(segment string) (expressionPart, error) {
+	pipeline := splitTopLevel(segment, '|')
+
+	part := expressionPart{
+		text:    strings.TrimSpace(pipeline[0]),
+		segment: true,
+	}
+	if part.text == "" {
+		return expressionPart{}, fmt.Errorf("empty JSONPath in $(%s)", segment)
+	}
+
+	for _, call := range pipeline[1:] {
+		function, err := parseFunctionCall(strings.TrimSpace(call))
+		if err != nil {
+			return expressionPart{}, err
+		}
+		part.functions = append(part.functions, function)
+	}
+
+	return part, nil
+}
+
+func parseFunctionCall(call string) (functionCall, error) {
+	name, rawArgs, hasArgs := strings.Cut(call, ":")
+	name = strings.TrimSpace(name)
+
+	if _, found := templateFunctions[name]; !found {
+		return functionCall{}, fmt.Errorf("unknown function %q", name)
+	}
+
+	function := functionCall{name: name}
+	if hasArgs {
+		for i, arg := range splitTopLevel(rawArgs, ',') {
+			arg = strings.TrimSpace(arg)
+			if strings.HasPrefix(arg, openPrefix+openBracket) && closingBracket(arg, 1) == len(arg)-1 {
+				argExpression, err := parseExpression(arg)
+				if err != nil {
+					return functionCall{}, err
+				}
+				if function.argExpressions == nil {
+					function.argExpressions = map[int]expression{}
+				}
+				function.argExpressions[i] = argExpression
+			}
+			function.args = append(function.args, unquote(arg))
+		}
+	}
+
+	return function, nil
+}
+
+// resolveArgs returns the arguments of the function call, evaluating arguments that are expressions.
+func (f functionCall) resolveArgs(values templateValues) ([]string, error) {
+	if len(f.argExpressions) == 0 {
+		return f.args, nil
+	}
+
+	args := make([]string, len(f.args))
+	copy(args, f.args)
+	for i, argExpression := range f.argExpressions {
+		rendered, err := argExpression.render(values)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+
+		var arg strings.Builder
+		for _, part := range rendered {
+			arg.WriteString(part.value)
+		}
+		args[i] = arg.String()
+	}
+	return args, nil
+}
+
+// render evaluates every part of the expression against values.
+func (e expression) render(values templateValues) ([]renderedPart, error) {
+	var rendered []renderedPart
+
+	for _, part := range e {
+		if !part.segment {
+			rendered = append(rendered, renderedPart{value: part.text})
+			continue
+		}
+
+		source := values.inputs
+		decoded := values.readsEncodedField(part.text)
+		if decoded {
+			source = values.decoded
+		}
+
+		results, err := evaluatePath(part.text, source)
+		if err != nil {
+			if _, isParseErr := err.(jsonPathParseError); isParseErr || !part.hasFunction(defaultFunction) {
+				return nil, err
+			}
+			// Values that can not be resolved, e.g. because they are read from an absent optional input resource,
+			// are left to the default function.
+			results = nil
+		}
+
+		for _, function := range part.functions {
+			// The default function only changes a value, and hence its encoding, when it falls back to its argument.
+			if function.name != defaultFunction || !hasValue(results) {
+				decoded = false
+			}
+
+			args, err := function.resolveArgs(values)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", function.name, err)
+			}
+
+			results, err = templateFunctions[function.name](results, args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", function.name, err)
+			}
+		}
+
+		rendered = append(rendered, renderedPart{value: strings.Join(results, " "), decoded: decoded})
+	}
+
+	return rendered, nil
+}
+
+func (p expressionPart) hasFunction(name string) bool {
+	for _, function := range p.functions {
+		if function.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPathParseError is returned when a JSONPath is malformed rather than not matching any values.
+type jsonPathParseError struct {
+	err error
+}
+
+func (e jsonPathParseError) Error() string { return e.err.Error() }
+
+// parsePath parses the JSONPath of a single $( ) segment.
+func parsePath(path string) (*jsonpath.JSONPath, error) {
+	parser := jsonpath.New("").AllowMissingKeys(false)
+	if err := parser.Parse(jsonPathOpen + JSONPath(path).ToK8sJSONPath() + jsonPathClose); err != nil {
+		return nil, jsonPathParseError{err}
+	}
+	return parser, nil
+}
+
+// evaluatePath evaluates a single JSONPath and returns the textual form of every value it matched.
+func evaluatePath(path string, values interface{}) ([]string, error) {
+	parser, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := parser.FindResults(values)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{}
+	for _, result := range results {
+		for _, value := range result {
+			buf := new(bytes.Buffer)
+			if err := parser.PrintResults(buf, []reflect.Value{value}); err != nil {
+				return nil, err
+			}
+			matches = append(matches, buf.String())
+		}
+	}
+
+	return matches, nil
+}
+
+// evaluateString evaluates an expression into its textual value.
+func evaluateString(expr string, values templateValues) (string, error) {
+	parsed, err := parseExpression(expr)
+	if err != nil {
+		return "", err
+	}
+
+	rendered, err := parsed.render(values)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	for _, part := range rendered {
+		result.WriteString(part.value)
+	}
+	return result.String(), nil
+}
+
+// evaluateData evaluates an expression into the bytes stored in the data of a Secret.
+// Values read from base64 encoded fields are stored as decoded. Any other value must itself be base64 encoded
+// and is decoded before being stored. The two can not be mixed within the same expression.
+func evaluateData(expr string, values templateValues) ([]byte, error) {
+	parsed, err := parseExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := parsed.render(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded, encoded strings.Builder
+	for _, part := range rendered {
+		if part.decoded {
+			decoded.WriteString(part.value)
+		} else {
+			encoded.WriteString(part.value)
+		}
+	}
+
+	if decoded.Len() > 0 && encoded.Len() > 0 {
+		return nil, fmt.Errorf("cannot combine values read from base64 encoded fields with other values, use stringData instead")
+	}
+	if encoded.Len() == 0 {
+		return []byte(decoded.String()), nil
+	}
+
+	result, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding base64: %w", err)
+	}
+	return result, nil
+}
+
+// closingBracket returns the position of the bracket closing the one opened at open, or -1.
+func closingBracket(s string, open int) int {
+	depth := 0
+	var quote byte
+
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == openBracket[0]:
+			depth++
+		case c == closeBracket[0]:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitTopLevel splits s around sep, ignoring separators that are quoted or nested in brackets.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bounds of the arguments of key derivation functions, so that templates can not stall reconciliation.
+const (
+	maxDerivedKeyLength = 1024
+	maxPBKDF2Iterations = 1000000
+)
+
+// templateFunction transforms the values a JSONPath segment resolved to.
+type templateFunction func(values []string, args []string) ([]string, error)
+
+// templateFunctions are the functions available to expressions, for example "$(.config.spec.caBundle | b64dec)".
+var templateFunctions = map[string]templateFunction{
+	"b64dec": eachValue(0, func(value string, _ []string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", err
+		}
+		return string(decoded), nil
+	}),
+	"b64enc": eachValue(0, func(value string, _ []string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	}),
+	"join": func(values []string, args []string) ([]string, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument(s), got %d", len(args))
+		}
+		return []string{strings.Join(values, args[0])}, nil
+	},
+	"prefix": eachValue(1, func(value string, args []string) (string, error) {
+		return args[0] + value, nil
+	}),
+	"suffix": eachValue(1, func(value string, args []string) (string, error) {
+		return value + args[0], nil
+	}),
+	"hex": eachValue(0, func(value string, _ []string) (string, error) {
+		return hex.EncodeToString([]byte(value)), nil
+	}),
+	"base32": eachValue(0, func(value string, _ []string) (string, error) {
+		return base32.StdEncoding.EncodeToString([]byte(value)), nil
+	}),
+	// Hash and key derivation functions return binary values, encode them using hex, base32 or b64enc.
+	"sha256": eachValue(0, func(value string, _ []string) (string, error) {
+		sum := sha256.Sum256([]byte(value))
+		return string(sum[:]), nil
+	}),
+	"sha512": eachValue(0, func(value string, _ []string) (string, error) {
+		sum := sha512.Sum512([]byte(value))
+		return string(sum[:]), nil
+	}),
+	"hmac_sha256": eachValue(1, func(value string, args []string) (string, error) {
+		mac := hmac.New(sha256.New, []byte(args[0]))
+		mac.Write([]byte(value))
+		return string(mac.Sum(nil)), nil
+	}),
+	"hkdf": eachValueRange(2, 3, func(value string, args []string) (string, error) {
+		length, err := intArg("length", args[1], 1, maxDerivedKeyLength)
+		if err != nil {
+			return "", err
+		}
+		var salt []byte
+		if len(args) == 3 {
+			salt = []byte(args[2])
+		}
+		key, err := hkdf.Key(sha256.New, []byte(value), salt, args[0], length)
+		if err != nil {
+			return "", err
+		}
+		return string(key), nil
+	}),
+	"pbkdf2": eachValue(3, func(value string, args []string) (string, error) {
+		iterations, err := intArg("iterations", args[1], 1, maxPBKDF2Iterations)
+		if err != nil {
+			return "", err
+		}
+		length, err := intArg("length", args[2], 1, maxDerivedKeyLength)
+		if err != nil {
+			return "", err
+		}
+		key, err := pbkdf2.Key(sha256.New, value, []byte(args[0]), iterations, length)
+		if err != nil {
+			return "", err
+		}
+		return string(key), nil
+	}),
+	defaultFunction: func(values []string, args []string) ([]string, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument(s), got %d", len(args))
+		}
+		if hasValue(values) {
+			return values, nil
+		}
+		return []string{args[0]}, nil
+	},
+}
+
+// defaultFunction replaces values that are empty or could not be resolved with its argument.
+const defaultFunction = "default"
+
+func hasValue(values []string) bool {
+	for _, value := range values {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// eachValue builds a templateFunction that applies fn to every value individually.
+func eachValue(numArgs int, fn func(value string, args []string) (string, error)) templateFunction {
+	return eachValueRange(numArgs, numArgs, fn)
+}
+
+// eachValueRange builds a templateFunction taking between minArgs and maxArgs arguments that applies fn to every value individually.
+func eachValueRange(minArgs, maxArgs int, fn func(value string, args []string) (string, error)) templateFunction {
+	return func(values []string, args []string) ([]string, error) {
+		if len(args) < minArgs || len(args) > maxArgs {
+			if minArgs == maxArgs {
+				return nil, fmt.Errorf("expected %d argument(s), got %d", minArgs, len(args))
+			}
+			return nil, fmt.Errorf("expected %d to %d arguments, got %d", minArgs, maxArgs, len(args))
+		}
+
+		results := make([]string, len(values))
+		for i, value := range values {
+			result, err := fn(value, args)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+		}
+		return results, nil
+	}
+}
+
+// intArg parses an integer argument and checks it lies within min and max.
+func intArg(name, arg string, min, max int) (int, error) {
+	value, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer, got %q", name, arg)
+	}
+	if value < min || value > max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %d", name, min, max, value)
+	}
+	return value, nil
+}
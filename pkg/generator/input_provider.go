@@ -0,0 +1,104 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+)
+
+// Kinds of input resources resolved by an InputProvider.
+const (
+	VaultInputProvider               = "vault"
+	ExternalInputProvider            = "external"
+	ServiceAccountTokenInputProvider = "serviceAccountToken"
+)
+
+// ErrInputNotFound is returned by an InputProvider when the input resource does not exist.
+var ErrInputNotFound = errors.New("input not found")
+
+// InputProvider resolves input resources from sources outside of the cluster, e.g. an external secret store.
+type InputProvider interface {
+	// Resolve returns the values of an input resource.
+	Resolve(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, input tsv1alpha1.InputResource) (ProvidedInput, error)
+}
+
+// ProvidedInput is an input resource resolved by an InputProvider.
+type ProvidedInput struct {
+	// Values are available to templates under the name of the input resource.
+	Values map[string]interface{}
+	// TTL is how long the values are valid for. The SecretTemplate is reconciled again once it has passed.
+	// Values without a TTL are refreshed at the reconciliation interval.
+	TTL time.Duration
+}
+
+type refreshKey struct{}
+
+// WithRefresh returns a context requesting input resources and tokens to be read from their source rather than a cache,
+// e.g. because reconciliation was requested through an annotation.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// RefreshRequested returns whether ctx requests values to be read from their source. InputProviders and TokenManagers
+// caching values honour it.
+func RefreshRequested(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
+
+func isInputNotFound(err error) bool {
+	return errors.Is(err, ErrInputNotFound)
+}
+
+// inputProviderKind returns the kind of InputProvider resolving an input resource,
+// or an empty string if the input resource is read from the Kubernetes API or a file.
+func inputProviderKind(input tsv1alpha1.InputResource) string {
+	switch {
+	case input.Vault != nil:
+		return VaultInputProvider
+	case input.External != nil:
+		return ExternalInputProvider
+	case input.ServiceAccountToken != nil:
+		return ServiceAccountTokenInputProvider
+	default:
+		return ""
+	}
+}
+
+// FileInputSource is the source of input resources read from files, see SetFileInputs.
+const FileInputSource = "file"
+
+// inputSourceName returns the source of an input resource other than the API server, or an empty string if it is read
+// from the API server.
+func inputSourceName(input tsv1alpha1.InputResource) string {
+	if input.File != nil {
+		return FileInputSource
+	}
+	return inputProviderKind(input)
+}
+
+// inputSources returns the number of sources an input resource refers to.
+func inputSources(input tsv1alpha1.InputResource) int {
+	sources := 0
+	if input.Ref.Kind != "" || input.Ref.Name != "" || input.Ref.Selector != nil {
+		sources++
+	}
+	if input.File != nil {
+		sources++
+	}
+	if input.Vault != nil {
+		sources++
+	}
+	if input.External != nil {
+		sources++
+	}
+	if input.ServiceAccountToken != nil {
+		sources++
+	}
+	return sources
+}
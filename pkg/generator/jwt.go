@@ -0,0 +1,146 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/jwt"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultJWTRefreshPercent = 80
+
+// mintJWTs returns the tokens of the JWTs of a template together with the time until the first of them needs to be minted again.
+// Tokens held by the existing Secret are reused until they pass their refresh point, or their signing key, claims or lifetime change.
+func mintJWTs(templates map[string]tsv1alpha1.JWTTemplate, values templateValues, existing *corev1.Secret, now time.Time) (map[string]string, time.Duration, error) {
+	var current map[string][]byte
+	if existing != nil {
+		current = secretData(*existing)
+	}
+
+	tokens := map[string]string{}
+	var refreshAfter time.Duration
+
+	for key, template := range templates {
+		token, refresh, err := mintJWT(template, values, string(current[key]), now)
+		if err != nil {
+			return nil, 0, fmt.Errorf("minting jwt %s: %w", key, err)
+		}
+		tokens[key] = token
+		if refresh > 0 && (refreshAfter == 0 || refresh < refreshAfter) {
+			refreshAfter = refresh
+		}
+	}
+
+	return tokens, refreshAfter, nil
+}
+
+// mintJWT returns a token for template, reusing the current token if it is still valid.
+func mintJWT(template tsv1alpha1.JWTTemplate, values templateValues, current string, now time.Time) (string, time.Duration, error) {
+	signingKey, err := evaluateString(template.SigningKey, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating signingKey: %w", err)
+	}
+	keyID, err := evaluateString(template.KeyID, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating keyID: %w", err)
+	}
+	claims, err := evaluate(template.Claims, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating claims: %w", err)
+	}
+	for _, claim := range []string{"iat", "exp"} {
+		if _, found := claims[claim]; found {
+			return "", 0, fmt.Errorf("claim %s is set by the controller", claim)
+		}
+	}
+
+	var lifetime time.Duration
+	if template.Lifetime != nil {
+		lifetime = template.Lifetime.Duration
+	}
+	if lifetime < 0 || (lifetime > 0 && lifetime < time.Second) {
+		return "", 0, fmt.Errorf("lifetime must be at least 1s")
+	}
+
+	refreshPercent := int64(template.RefreshPercent)
+	if refreshPercent == 0 {
+		refreshPercent = defaultJWTRefreshPercent
+	}
+	if refreshPercent < 1 || refreshPercent > 100 {
+		return "", 0, fmt.Errorf("refreshPercent must be between 1 and 100")
+	}
+	refreshIn := max(time.Duration(int64(lifetime)*refreshPercent/100), time.Second)
+
+	if current != "" {
+		header, currentClaims, err := jwt.Verify(current, template.Algorithm, []byte(signingKey))
+		if err == nil && header.KeyID == keyID {
+			if issuedAt, ok := reusableJWT(currentClaims, claims, lifetime); ok {
+				if lifetime == 0 {
+					return current, 0, nil
+				}
+				if remaining := issuedAt.Add(refreshIn).Sub(now); remaining > 0 {
+					return current, remaining, nil
+				}
+			}
+		}
+	}
+
+	tokenClaims := map[string]interface{}{"iat": now.Unix()}
+	for claim, value := range claims {
+		tokenClaims[claim] = value
+	}
+	if lifetime > 0 {
+		tokenClaims["exp"] = now.Unix() + int64(lifetime/time.Second)
+	}
+
+	token, err := jwt.Sign(template.Algorithm, []byte(signingKey), keyID, tokenClaims)
+	if err != nil {
+		return "", 0, err
+	}
+	if lifetime == 0 {
+		return token, 0, nil
+	}
+	return token, refreshIn, nil
+}
+
+// reusableJWT returns the issue time of a token if it carries the desired claims and lifetime.
+func reusableJWT(current map[string]interface{}, claims map[string]string, lifetime time.Duration) (time.Time, bool) {
+	issuedAt, ok := numericClaim(current, "iat")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	expectedClaims := len(claims) + 1
+	if lifetime > 0 {
+		expiresAt, ok := numericClaim(current, "exp")
+		if !ok || expiresAt-issuedAt != int64(lifetime/time.Second) {
+			return time.Time{}, false
+		}
+		expectedClaims++
+	}
+	if len(current) != expectedClaims {
+		return time.Time{}, false
+	}
+
+	for claim, value := range claims {
+		if current[claim] != value {
+			return time.Time{}, false
+		}
+	}
+	return time.Unix(issuedAt, 0), true
+}
+
+func numericClaim(claims map[string]interface{}, claim string) (int64, bool) {
+	number, ok := claims[claim].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	value, err := number.Int64()
+	return value, err == nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const defaultKubeconfigTokenExpiration = int64(3600)
+
+// kubeconfigs returns the kubeconfigs of a SecretTemplate together with the time until the first of their tokens needs to be refreshed.
+func (r *SecretTemplateReconciler) kubeconfigs(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, values templateValues) (map[string]string, time.Duration, error) {
+	kubeconfigs := map[string]string{}
+	var refreshAfter time.Duration
+
+	for key, template := range secretTemplate.Spec.JSONPathTemplate.Kubeconfigs {
+		kubeconfig, refresh, err := r.kubeconfig(ctx, secretTemplate, template, values)
+		if err != nil {
+			return nil, 0, fmt.Errorf("templating kubeconfig %s: %w", key, err)
+		}
+		kubeconfigs[key] = kubeconfig
+		if refresh > 0 && (refreshAfter == 0 || refresh < refreshAfter) {
+			refreshAfter = refresh
+		}
+	}
+
+	return kubeconfigs, refreshAfter, nil
+}
+
+func (r *SecretTemplateReconciler) kubeconfig(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, template tsv1alpha1.KubeconfigTemplate,
+	values templateValues) (string, time.Duration, error) {
+	if (template.Token != "") == (template.ServiceAccountToken != nil) {
+		return "", 0, fmt.Errorf("exactly one of token or serviceAccountToken must be set")
+	}
+
+	server, err := evaluateString(template.Server, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating server: %w", err)
+	}
+	certificateAuthority, err := evaluateString(template.CertificateAuthority, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating certificateAuthority: %w", err)
+	}
+	name, err := evaluateString(template.Name, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating name: %w", err)
+	}
+	namespace, err := evaluateString(template.Namespace, values)
+	if err != nil {
+		return "", 0, fmt.Errorf("templating namespace: %w", err)
+	}
+
+	if server == "" {
+		if r.clusterServer == "" {
+			return "", 0, fmt.Errorf("server must be set")
+		}
+		server = r.clusterServer
+		if certificateAuthority == "" {
+			certificateAuthority = string(r.clusterCA)
+		}
+	}
+	if certificateAuthority != "" {
+		if _, err := certutil.NewPoolFromBytes([]byte(certificateAuthority)); err != nil {
+			return "", 0, fmt.Errorf("parsing certificateAuthority: %w", err)
+		}
+	}
+	if name == "" {
+		name = secretTemplate.Name
+	}
+
+	var token string
+	var refreshAfter time.Duration
+	if template.ServiceAccountToken != nil {
+		token, refreshAfter, err = r.kubeconfigToken(ctx, secretTemplate, *template.ServiceAccountToken)
+		if err != nil {
+			return "", 0, err
+		}
+	} else {
+		token, err = evaluateString(template.Token, values)
+		if err != nil {
+			return "", 0, fmt.Errorf("templating token: %w", err)
+		}
+	}
+
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			name: {Server: server, CertificateAuthorityData: []byte(certificateAuthority)},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			name: {Token: token},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			name: {Cluster: name, AuthInfo: name, Namespace: namespace},
+		},
+		CurrentContext: name,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return string(kubeconfig), refreshAfter, nil
+}
+
+// kubeconfigToken issues a token for the ServiceAccount of a SecretTemplate and returns it together with the time
+// after which the token manager hands out a fresh token. The token defaults to the audiences of the API server, as
+// kubeconfigs authenticate to it.
+func (r *SecretTemplateReconciler) kubeconfigToken(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate,
+	serviceAccountToken tsv1alpha1.KubeconfigServiceAccountToken) (string, time.Duration, error) {
+	if secretTemplate.Spec.GetServiceAccountName() == "" {
+		return "", 0, fmt.Errorf("serviceAccountToken requires a serviceAccountName to be set")
+	}
+	if r.tokenIssuer == nil {
+		return "", 0, fmt.Errorf("service account tokens are not enabled")
+	}
+
+	expiration := defaultKubeconfigTokenExpiration
+	if serviceAccountToken.ExpirationSeconds != nil {
+		expiration = *serviceAccountToken.ExpirationSeconds
+	}
+	tokenRequest, err := r.tokenIssuer.IssueToken(ctx, secretTemplate, secretTemplate.Spec.GetServiceAccountName(), &authv1.TokenRequest{
+		Spec: authv1.TokenRequestSpec{
+			Audiences:         serviceAccountToken.Audiences,
+			ExpirationSeconds: &expiration,
+		},
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("requesting service account token: %w", err)
+	}
+
+	// Tokens are refreshed by the token manager once half of their remaining lifetime has passed
+	refreshAfter := max(time.Until(tokenRequest.Status.ExpirationTimestamp.Time)/2, time.Second)
+	return tokenRequest.Status.Token, refreshAfter, nil
+}
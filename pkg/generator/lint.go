@@ -0,0 +1,196 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Rules checked by Lint.
+const (
+	LintRuleExpressionSyntax = "expression-syntax"
+	LintRuleUndeclaredInput  = "undeclared-input"
+	LintRuleForwardReference = "forward-reference"
+	LintRuleDuplicateKey     = "duplicate-key"
+	LintRuleSecretOnly       = "secret-only"
+	LintRuleInvalidKey       = "invalid-key"
+)
+
+// LintRules describes the rules checked by Lint by their ID.
+var LintRules = map[string]string{
+	LintRuleExpressionSyntax: "Expressions must be valid JSONPaths surrounded by $( ), optionally piped through known functions.",
+	LintRuleUndeclaredInput:  "Expressions and dataFrom can only refer to declared input resources.",
+	LintRuleForwardReference: "The name of an input resource can only refer to input resources declared before it.",
+	LintRuleDuplicateKey:     "A key of the Secret can only be defined once across data, stringData, uris, jwts and kubeconfigs.",
+	LintRuleSecretOnly:       "Input resources other than Secrets can only be read with a serviceAccountName.",
+	LintRuleInvalidKey:       "Keys of the Secret must consist of alphanumeric characters, '-', '_' or '.'.",
+}
+
+// LintFinding is a problem Lint found in a SecretTemplate.
+type LintFinding struct {
+	// Rule is the ID of the rule that found the problem.
+	Rule string `json:"rule"`
+	// Field is the path of the field containing the problem, e.g. spec.template.data[password].
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Lint statically checks a SecretTemplate for problems that fail or change its rendering, without reading any
+// input resources. Findings are returned in the order of the fields they were found in.
+func Lint(secretTemplate *tsv1alpha1.SecretTemplate) []LintFinding {
+	l := linter{declared: map[string]int{}}
+	for i, input := range secretTemplate.Spec.InputResources {
+		if _, found := l.declared[input.Name]; !found {
+			l.declared[input.Name] = i
+		}
+	}
+
+	for i, input := range secretTemplate.Spec.InputResources {
+		if input.File != nil || inputProviderKind(input) != "" {
+			continue
+		}
+
+		field := fmt.Sprintf("spec.inputResources[%d].ref", i)
+		if secretTemplate.Spec.GetServiceAccountName() == "" && (input.Ref.Kind != "Secret" || input.Ref.APIVersion != "v1") {
+			l.add(LintRuleSecretOnly, field, "input resource %s refers to a %s, which can only be read with a serviceAccountName", input.Name, input.Ref.Kind)
+		}
+		// Names are evaluated against the input resources read so far.
+		l.expression(field+".name", input.Ref.Name, i)
+	}
+
+	template := secretTemplate.Spec.JSONPathTemplate
+	if template != nil {
+		l.template(template, len(secretTemplate.Spec.InputResources))
+	}
+	if secretTemplate.Spec.EncryptedOutput != nil {
+		l.expression("spec.encryptedOutput.recipients", secretTemplate.Spec.EncryptedOutput.Recipients, len(secretTemplate.Spec.InputResources))
+	}
+
+	return l.findings
+}
+
+type linter struct {
+	// declared holds the position of each input resource by name.
+	declared map[string]int
+	findings []LintFinding
+}
+
+func (l *linter) add(rule, field, format string, args ...interface{}) {
+	l.findings = append(l.findings, LintFinding{Rule: rule, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) template(template *tsv1alpha1.JSONPathTemplate, inputs int) {
+	// definedIn holds the field each key of the Secret is first defined in.
+	definedIn := map[string]string{}
+	key := func(field, key string) {
+		keyField := fmt.Sprintf("spec.template.%s[%s]", field, key)
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			l.add(LintRuleInvalidKey, keyField, "key %q is not a valid Secret key: %s", key, strings.Join(errs, ", "))
+		}
+		if other, found := definedIn[key]; found {
+			l.add(LintRuleDuplicateKey, keyField, "key %s is also defined in %s", key, other)
+			return
+		}
+		definedIn[key] = field
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(template.Data)) {
+		key("data", k)
+		l.expression(fmt.Sprintf("spec.template.data[%s]", k), template.Data[k], inputs)
+	}
+	for _, k := range slices.Sorted(maps.Keys(template.StringData)) {
+		key("stringData", k)
+		l.expression(fmt.Sprintf("spec.template.stringData[%s]", k), template.StringData[k], inputs)
+	}
+	for _, k := range slices.Sorted(maps.Keys(template.URIs)) {
+		key("uris", k)
+		uri := template.URIs[k]
+		field := fmt.Sprintf("spec.template.uris[%s]", k)
+		l.expression(field+".scheme", uri.Scheme, inputs)
+		l.expression(field+".username", uri.Username, inputs)
+		l.expression(field+".password", uri.Password, inputs)
+		l.expression(field+".host", uri.Host, inputs)
+		l.expression(field+".port", uri.Port, inputs)
+		l.expression(field+".path", uri.Path, inputs)
+		l.expressions(field+".query", uri.Query, inputs)
+	}
+	for _, k := range slices.Sorted(maps.Keys(template.JWTs)) {
+		key("jwts", k)
+		jwt := template.JWTs[k]
+		field := fmt.Sprintf("spec.template.jwts[%s]", k)
+		l.expression(field+".signingKey", jwt.SigningKey, inputs)
+		l.expression(field+".keyID", jwt.KeyID, inputs)
+		l.expressions(field+".claims", jwt.Claims, inputs)
+	}
+	for _, k := range slices.Sorted(maps.Keys(template.Kubeconfigs)) {
+		key("kubeconfigs", k)
+		kubeconfig := template.Kubeconfigs[k]
+		field := fmt.Sprintf("spec.template.kubeconfigs[%s]", k)
+		l.expression(field+".server", kubeconfig.Server, inputs)
+		l.expression(field+".certificateAuthority", kubeconfig.CertificateAuthority, inputs)
+		l.expression(field+".name", kubeconfig.Name, inputs)
+		l.expression(field+".namespace", kubeconfig.Namespace, inputs)
+		l.expression(field+".token", kubeconfig.Token, inputs)
+	}
+
+	for i, source := range template.DataFrom {
+		if _, found := l.declared[source.InputResource]; !found {
+			l.add(LintRuleUndeclaredInput, fmt.Sprintf("spec.template.dataFrom[%d].inputResource", i), "input resource %s is not declared", source.InputResource)
+		}
+	}
+
+	l.expressions("spec.template.metadata.labels", template.Metadata.Labels, inputs)
+	l.expressions("spec.template.metadata.annotations", template.Metadata.Annotations, inputs)
+	l.expression("spec.template.type", string(template.Type), inputs)
+}
+
+// expressions checks the values of a mapping, e.g. labels.
+func (l *linter) expressions(field string, mapping map[string]string, inputs int) {
+	for _, k := range slices.Sorted(maps.Keys(mapping)) {
+		l.expression(fmt.Sprintf("%s[%s]", field, k), mapping[k], inputs)
+	}
+}
+
+// expression checks an expression that is evaluated against the first inputs input resources.
+func (l *linter) expression(field, expr string, inputs int) {
+	parsed, err := parseExpression(expr)
+	if err != nil {
+		l.add(LintRuleExpressionSyntax, field, "%s", err)
+		return
+	}
+	l.parts(field, parsed, inputs)
+}
+
+func (l *linter) parts(field string, parsed expression, inputs int) {
+	for _, part := range parsed {
+		if !part.segment {
+			continue
+		}
+
+		if _, err := parsePath(part.text); err != nil {
+			l.add(LintRuleExpressionSyntax, field, "invalid JSONPath %q: %s", part.text, err)
+		} else if match := inputNamePath.FindStringSubmatch(part.text); match != nil {
+			name := strings.ReplaceAll(match[1], `\.`, ".")
+			position, found := l.declared[name]
+			switch {
+			case !found:
+				l.add(LintRuleUndeclaredInput, field, "input resource %s is not declared", name)
+			case position >= inputs:
+				l.add(LintRuleForwardReference, field, "input resource %s is not read yet, only input resources declared before spec.inputResources[%d] can be referred to", name, inputs)
+			}
+		}
+
+		for _, function := range part.functions {
+			for _, i := range slices.Sorted(maps.Keys(function.argExpressions)) {
+				l.parts(field, function.argExpressions[i], inputs)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator_test
+
+import (
+	"testing"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Lint(t *testing.T) {
+	secretInput := func(name, secretName string) tsv1alpha1.InputResource {
+		return tsv1alpha1.InputResource{Name: name, Ref: tsv1alpha1.InputResourceRef{APIVersion: "v1", Kind: "Secret", Name: secretName}}
+	}
+
+	type test struct {
+		name     string
+		spec     tsv1alpha1.SecretTemplateSpec
+		expected []generator.LintFinding
+	}
+
+	tests := []test{
+		{
+			name: "valid template",
+			spec: tsv1alpha1.SecretTemplateSpec{
+				InputResources: []tsv1alpha1.InputResource{
+					secretInput("creds", "creds"),
+					secretInput("other", "$(.creds.data.other)"),
+				},
+				JSONPathTemplate: &tsv1alpha1.JSONPathTemplate{
+					Data:       map[string]string{"password": "$(.creds.data.password)"},
+					StringData: map[string]string{"token": "$(.other.data.body | hmac_sha256:$(.creds.data.key))"},
+					DataFrom:   []tsv1alpha1.DataFromSource{{InputResource: "other"}},
+				},
+			},
+		},
+		{
+			name: "invalid expressions",
+			spec: tsv1alpha1.SecretTemplateSpec{
+				InputResources: []tsv1alpha1.InputResource{secretInput("creds", "creds")},
+				JSONPathTemplate: &tsv1alpha1.JSONPathTemplate{
+					StringData: map[string]string{
+						"host":     "$(.creds.data[)",
+						"password": "$(.creds.data.password | nosuchfn)",
+					},
+				},
+			},
+			expected: []generator.LintFinding{
+				{Rule: generator.LintRuleExpressionSyntax, Field: "spec.template.stringData[host]", Message: `invalid JSONPath ".creds.data[": unterminated array`},
+				{Rule: generator.LintRuleExpressionSyntax, Field: "spec.template.stringData[password]", Message: `unknown function "nosuchfn"`},
+			},
+		},
+		{
+			name: "undeclared and forward references",
+			spec: tsv1alpha1.SecretTemplateSpec{
+				InputResources: []tsv1alpha1.InputResource{
+					secretInput("first", "$(.second.data.name)"),
+					secretInput("second", "second"),
+				},
+				JSONPathTemplate: &tsv1alpha1.JSONPathTemplate{
+					StringData: map[string]string{"token": "$(.first.data.body | hmac_sha256:$(.missing.data.key))"},
+					DataFrom:   []tsv1alpha1.DataFromSource{{InputResource: "absent"}},
+				},
+			},
+			expected: []generator.LintFinding{
+				{Rule: generator.LintRuleForwardReference, Field: "spec.inputResources[0].ref.name",
+					Message: "input resource second is not read yet, only input resources declared before spec.inputResources[0] can be referred to"},
+				{Rule: generator.LintRuleUndeclaredInput, Field: "spec.template.stringData[token]", Message: "input resource missing is not declared"},
+				{Rule: generator.LintRuleUndeclaredInput, Field: "spec.template.dataFrom[0].inputResource", Message: "input resource absent is not declared"},
+			},
+		},
+		{
+			name: "duplicate and invalid keys",
+			spec: tsv1alpha1.SecretTemplateSpec{
+				InputResources: []tsv1alpha1.InputResource{secretInput("creds", "creds")},
+				JSONPathTemplate: &tsv1alpha1.JSONPathTemplate{
+					Data:       map[string]string{"password": "$(.creds.data.password)"},
+					StringData: map[string]string{"password": "static", "user/name": "admin"},
+					URIs:       map[string]tsv1alpha1.URITemplate{"password": {Scheme: "postgres", Host: "db"}},
+				},
+			},
+			expected: []generator.LintFinding{
+				{Rule: generator.LintRuleDuplicateKey, Field: "spec.template.stringData[password]", Message: "key password is also defined in data"},
+				{Rule: generator.LintRuleInvalidKey, Field: "spec.template.stringData[user/name]",
+					Message: `key "user/name" is not a valid Secret key: a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')`},
+				{Rule: generator.LintRuleDuplicateKey, Field: "spec.template.uris[password]", Message: "key password is also defined in data"},
+			},
+		},
+		{
+			name: "non-secrets without service account",
+			spec: tsv1alpha1.SecretTemplateSpec{
+				InputResources: []tsv1alpha1.InputResource{
+					{Name: "svc", Ref: tsv1alpha1.InputResourceRef{APIVersion: "v1", Kind: "Service", Name: "db"}},
+					{Name: "ca", File: &tsv1alpha1.FileInputSource{Path: "ca.crt"}},
+				},
+				JSONPathTemplate: &tsv1alpha1.JSONPathTemplate{
+					StringData: map[string]string{"host": "$(.svc.spec.clusterIP)"},
+				},
+			},
+			expected: []generator.LintFinding{
+				{Rule: generator.LintRuleSecretOnly, Field: "spec.inputResources[0].ref",
+					Message: "input resource svc refers to a Service, which can only be read with a serviceAccountName"},
+			},
+		},
+		{
+			name: "non-secrets with service account",
+			spec: tsv1alpha1.SecretTemplateSpec{
+				ServiceAccountName: "reader",
+				InputResources: []tsv1alpha1.InputResource{
+					{Name: "svc", Ref: tsv1alpha1.InputResourceRef{APIVersion: "v1", Kind: "Service", Name: "db"}},
+				},
+				JSONPathTemplate: &tsv1alpha1.JSONPathTemplate{
+					StringData: map[string]string{"host": "$(.svc.spec.clusterIP)"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := generator.Lint(&tsv1alpha1.SecretTemplate{Spec: tc.spec})
+			assert.Equal(t, tc.expected, findings)
+		})
+	}
+}
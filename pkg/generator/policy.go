@@ -0,0 +1,173 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/reconciler"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// PolicyViolationReason is the reason of the ReconcileFailed condition of SecretTemplates violating a SecretTemplatePolicy.
+const PolicyViolationReason = "PolicyViolation"
+
+// policyViolations counts reconciliations refused by a SecretTemplatePolicy, exposed alongside the controller-runtime metrics.
+var policyViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "templatedsecret_policy_violations_total",
+	Help: "Number of SecretTemplate reconciliations refused by a SecretTemplatePolicy.",
+}, []string{"namespace", "policy"})
+
+func init() {
+	metrics.Registry.MustRegister(policyViolations)
+}
+
+// policiesFor returns the SecretTemplatePolicies applying to SecretTemplates in namespace, sorted by name.
+func (r *SecretTemplateReconciler) policiesFor(ctx context.Context, namespace string) ([]tsv1alpha1.SecretTemplatePolicy, error) {
+	list := tsv1alpha1.SecretTemplatePolicyList{}
+	if err := r.client.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("listing SecretTemplatePolicies: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+
+	ns := corev1.Namespace{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return nil, fmt.Errorf("fetching namespace %s: %w", namespace, err)
+	}
+
+	var policies []tsv1alpha1.SecretTemplatePolicy
+	for _, policy := range list.Items {
+		selector := labels.Everything()
+		if policy.Spec.NamespaceSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespace selector of SecretTemplatePolicy %s: %w", policy.Name, err)
+			}
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			policies = append(policies, policy)
+		}
+	}
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, nil
+}
+
+// secretTemplatesForPolicy enqueues all SecretTemplates when a SecretTemplatePolicy changes, as changes of its namespace
+// selector can affect SecretTemplates in any namespace.
+func (r *SecretTemplateReconciler) secretTemplatesForPolicy(ctx context.Context, _ *tsv1alpha1.SecretTemplatePolicy) []reconcile.Request {
+	secretTemplates := tsv1alpha1.SecretTemplateList{}
+	if err := r.client.List(ctx, &secretTemplates); err != nil {
+		r.log.Error(err, "Listing SecretTemplates affected by SecretTemplatePolicy")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(secretTemplates.Items))
+	for _, secretTemplate := range secretTemplates.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: secretTemplate.Namespace,
+			Name:      secretTemplate.Name,
+		}})
+	}
+	return requests
+}
+
+// checkInputResourceCount checks the number of input resources of a SecretTemplate against the policies applying to it.
+func checkInputResourceCount(policies []tsv1alpha1.SecretTemplatePolicy, secretTemplate *tsv1alpha1.SecretTemplate) error {
+	for _, policy := range policies {
+		if limit := policy.Spec.MaxInputResources; limit != nil && len(secretTemplate.Spec.InputResources) > int(*limit) {
+			return policyViolation(secretTemplate.Namespace, policy.Name, fmt.Errorf("SecretTemplate has %d input resources, SecretTemplatePolicy %s allows at most %d",
+				len(secretTemplate.Spec.InputResources), policy.Name, *limit))
+		}
+	}
+	return nil
+}
+
+// checkInputSource checks the source of an input resource not read from the API server against the policies applying to
+// the SecretTemplate reading it. Such input resources can not be matched by rules, so each policy must allow their source.
+func checkInputSource(policies []tsv1alpha1.SecretTemplatePolicy, namespace string, input tsv1alpha1.InputResource) error {
+	source := inputSourceName(input)
+	if source == "" {
+		return nil
+	}
+	for _, policy := range policies {
+		if !slices.Contains(policy.Spec.AllowedSources, tsv1alpha1.InputSource(source)) {
+			return policyViolation(namespace, policy.Name, fmt.Errorf("input resource %s reads from %s, which is not allowed by SecretTemplatePolicy %s",
+				input.Name, source, policy.Name))
+		}
+	}
+	return nil
+}
+
+// checkInputResource checks an input resource read from the API server against the policies applying to the SecretTemplate reading it.
+func checkInputResource(policies []tsv1alpha1.SecretTemplatePolicy, inputName string, ref tsv1alpha1.InputResourceRef, namespace, name string) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return err
+	}
+
+	matches := func(rule tsv1alpha1.InputResourceRule) bool {
+		return matchesAny(rule.APIGroups, gv.Group, false) &&
+			matchesAny(rule.Kinds, ref.Kind, false) &&
+			matchesAny(rule.Namespaces, namespace, true) &&
+			matchesAny(rule.Names, name, true)
+	}
+
+	for _, policy := range policies {
+		if len(policy.Spec.Allow) > 0 && !slices.ContainsFunc(policy.Spec.Allow, matches) {
+			return policyViolation(namespace, policy.Name, fmt.Errorf("input resource %s (%s %s/%s) is not allowed by SecretTemplatePolicy %s",
+				inputName, ref.Kind, namespace, name, policy.Name))
+		}
+		if slices.ContainsFunc(policy.Spec.Deny, matches) {
+			return policyViolation(namespace, policy.Name, fmt.Errorf("input resource %s (%s %s/%s) is denied by SecretTemplatePolicy %s",
+				inputName, ref.Kind, namespace, name, policy.Name))
+		}
+	}
+	return nil
+}
+
+// matchesAny returns whether value is one of values, or any value if values is empty or contains "*". If patterns is set,
+// values may also be shell patterns like "team-*".
+func matchesAny(values []string, value string, patterns bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+		if patterns {
+			if matched, _ := path.Match(v, value); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// policyViolation records a violation of a SecretTemplatePolicy. Violations are terminal, SecretTemplates are reconciled
+// again once they or a policy change.
+func policyViolation(namespace, policy string, err error) error {
+	policyViolations.WithLabelValues(namespace, policy).Inc()
+	return reconciler.TerminalReconcileErr{Err: err, Reason: PolicyViolationReason}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"bytes"
+	"sort"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// previewSecret describes how writing rendered would change current, which is nil if the Secret does not exist.
+// desiredType is the type of the rendered Secret before the type of an existing Secret is retained.
+func previewSecret(current *corev1.Secret, rendered corev1.Secret, desiredType corev1.SecretType) *tsv1alpha1.SecretPreview {
+	if current == nil {
+		current = &corev1.Secret{}
+	}
+
+	preview := &tsv1alpha1.SecretPreview{
+		Create:      current.ResourceVersion == "",
+		Data:        keyChanges(secretData(*current), secretData(rendered), bytes.Equal),
+		Labels:      keyChanges(current.Labels, rendered.Labels, stringsEqual),
+		Annotations: keyChanges(current.Annotations, rendered.Annotations, stringsEqual),
+	}
+	if secretType(current.Type) != secretType(desiredType) {
+		preview.Type = secretType(desiredType)
+	}
+	return preview
+}
+
+func keyChanges[V any](current, desired map[string]V, equal func(a, b V) bool) tsv1alpha1.KeyChanges {
+	var changes tsv1alpha1.KeyChanges
+	for key, value := range desired {
+		currentValue, found := current[key]
+		switch {
+		case !found:
+			changes.Added = append(changes.Added, key)
+		case !equal(currentValue, value):
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	for key := range current {
+		if _, found := desired[key]; !found {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+	return changes
+}
+
+func stringsEqual(a, b string) bool {
+	return a == b
+}
+
+// secretType returns the type the API server stores a Secret of type t as.
+func secretType(t corev1.SecretType) corev1.SecretType {
+	if t == "" {
+		return corev1.SecretTypeOpaque
+	}
+	return t
+}
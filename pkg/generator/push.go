@@ -0,0 +1,137 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Kinds of push targets written by a PushSink.
+const (
+	VaultPushSink = "vault"
+	HTTPPushSink  = "http"
+)
+
+// PushSink writes the data of Secrets to stores outside of the cluster.
+type PushSink interface {
+	// Read returns the data currently held by a push target. found is false if the target holds no data.
+	Read(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget) (data map[string]string, found bool, err error)
+	// Write replaces the data held by a push target.
+	Write(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget, data map[string]string) error
+}
+
+// pushSinkKind returns the kind of PushSink writing a push target.
+func pushSinkKind(target tsv1alpha1.PushTarget) string {
+	switch {
+	case target.Vault != nil:
+		return VaultPushSink
+	case target.HTTP != nil:
+		return HTTPPushSink
+	default:
+		return ""
+	}
+}
+
+// pushSinks returns the number of stores a push target refers to.
+func pushSinks(target tsv1alpha1.PushTarget) int {
+	sinks := 0
+	if target.Vault != nil {
+		sinks++
+	}
+	if target.HTTP != nil {
+		sinks++
+	}
+	return sinks
+}
+
+// push writes the data of secret to all push targets of a SecretTemplate and records their status.
+// Targets are written independently, a failing target does not prevent other targets from being written.
+func (r *SecretTemplateReconciler) push(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, secret corev1.Secret) error {
+	previous := map[string]tsv1alpha1.PushTargetStatus{}
+	for _, status := range secretTemplate.Status.Push {
+		previous[status.Name] = status
+	}
+
+	data := map[string]string{}
+	for key, value := range secretData(secret) {
+		data[key] = string(value)
+	}
+
+	seen := map[string]bool{}
+	var statuses []tsv1alpha1.PushTargetStatus
+	var errs []error
+
+	for _, target := range secretTemplate.Spec.Push {
+		if seen[target.Name] {
+			return fmt.Errorf("push target %s is defined more than once", target.Name)
+		}
+		seen[target.Name] = true
+
+		status := previous[target.Name]
+		status.Name = target.Name
+
+		if err := r.pushTarget(ctx, secretTemplate, target, secret.ResourceVersion, data, &status); err != nil {
+			status.Synced = false
+			status.Message = err.Error()
+			errs = append(errs, fmt.Errorf("pushing to %s: %w", target.Name, err))
+		} else {
+			status.Message = ""
+		}
+		statuses = append(statuses, status)
+	}
+
+	secretTemplate.Status.Push = statuses
+	return utilerrors.NewAggregate(errs)
+}
+
+// pushTarget writes data to a push target unless it already holds it.
+func (r *SecretTemplateReconciler) pushTarget(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget,
+	secretResourceVersion string, data map[string]string, status *tsv1alpha1.PushTargetStatus) error {
+	if pushSinks(target) != 1 {
+		return fmt.Errorf("exactly one of vault or http must be set")
+	}
+
+	kind := pushSinkKind(target)
+	sink, found := r.sinks[kind]
+	if !found {
+		return fmt.Errorf("%s push targets are not enabled", kind)
+	}
+
+	current, found, err := sink.Read(ctx, secretTemplate, target)
+	if err != nil {
+		return fmt.Errorf("reading current data: %w", err)
+	}
+
+	if found && maps.Equal(current, data) {
+		status.Synced = true
+		status.SecretResourceVersion = secretResourceVersion
+		return nil
+	}
+
+	// The secret did not change since it was last written, so the target has been changed or deleted outside of the controller.
+	if status.SecretResourceVersion == secretResourceVersion {
+		now := metav1.Now()
+		status.LastDriftTime = &now
+		r.log.Info("Push target drifted from secret, overwriting it",
+			"secretTemplate", secretTemplate.Name,
+			"target", target.Name)
+	}
+
+	if err := sink.Write(ctx, secretTemplate, target, data); err != nil {
+		return fmt.Errorf("writing data: %w", err)
+	}
+
+	now := metav1.Now()
+	status.Synced = true
+	status.SecretResourceVersion = secretResourceVersion
+	status.LastPushTime = &now
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/tracker"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Render resolves the input resources of secretTemplate from objects held in memory and returns the Secret it would
+// produce, without a cluster. Objects without a namespace are placed in the namespace of the SecretTemplate, a Secret
+// named like the SecretTemplate is treated as the existing Secret. Input resources read from files, Vault or external
+// providers and service account tokens are not supported.
+func Render(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, objects []*unstructured.Unstructured) (corev1.Secret, error) {
+	secretTemplate = secretTemplate.DeepCopy()
+	secret, err := renderOffline(ctx, secretTemplate, objects)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+
+	// stringData is merged into data, as the API server does when storing the Secret.
+	return corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		},
+		Type: secretType(secret.Type),
+		Data: secretData(secret),
+	}, nil
+}
+
+// Explain renders secretTemplate like Render and describes how each key of the Secret is resolved. The explanation
+// is returned along with the error if the Secret can not be rendered, as long as the input resources could be read.
+func Explain(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, objects []*unstructured.Unstructured) ([]tsv1alpha1.KeyExplanation, error) {
+	secretTemplate = secretTemplate.DeepCopy()
+	metav1.SetMetaDataAnnotation(&secretTemplate.ObjectMeta, ExplainAnnKey, "true")
+	_, err := renderOffline(ctx, secretTemplate, objects)
+	return secretTemplate.Status.Explanation, err
+}
+
+// renderOffline renders secretTemplate from objects held in memory, returning the Secret it would write. The status of
+// secretTemplate is updated in place.
+func renderOffline(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, objects []*unstructured.Unstructured) (corev1.Secret, error) {
+	if secretTemplate.Namespace == "" {
+		secretTemplate.Namespace = "default"
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return corev1.Secret{}, err
+	}
+	if err := tsv1alpha1.AddToScheme(scheme); err != nil {
+		return corev1.Secret{}, err
+	}
+
+	source := &objectSource{scheme: scheme}
+	for _, object := range objects {
+		object = object.DeepCopy()
+		if object.GetNamespace() == "" {
+			object.SetNamespace(secretTemplate.Namespace)
+		}
+		if err := mergeStringData(object); err != nil {
+			return corev1.Secret{}, fmt.Errorf("%s %s: %w", object.GetKind(), object.GetName(), err)
+		}
+		source.objects = append(source.objects, object)
+	}
+
+	r := NewSecretTemplateReconciler(nil, nil, nil, tracker.NewTracker(), logr.Discard())
+	r.objectSource = source
+
+	secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretTemplate.Name, Namespace: secretTemplate.Namespace}}
+	var existing *corev1.Secret
+	if err := source.Get(ctx, client.ObjectKeyFromObject(&secret), &secret); err == nil {
+		existing = secret.DeepCopy()
+	} else if !errors.IsNotFound(err) {
+		return corev1.Secret{}, err
+	}
+
+	templated, err := r.render(ctx, secretTemplate, existing, false)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	r.applyTemplate(secretTemplate, &secret, templated, false)
+	return secret, nil
+}
+
+// objectSource is a read-only client.Reader over objects held in memory.
+type objectSource struct {
+	scheme  *runtime.Scheme
+	objects []*unstructured.Unstructured
+}
+
+var _ client.Reader = &objectSource{}
+
+// Get returns the object of the kind of obj with the given key.
+func (s *objectSource) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	gvk, err := apiutil.GVKForObject(obj, s.scheme)
+	if err != nil {
+		return err
+	}
+	for _, object := range s.objects {
+		if object.GroupVersionKind() == gvk && object.GetNamespace() == key.Namespace && object.GetName() == key.Name {
+			return into(object, obj)
+		}
+	}
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	return errors.NewNotFound(resource.GroupResource(), key.Name)
+}
+
+// List returns the objects of the kind of list matching the namespace and label selector of opts. Only unstructured
+// lists are supported.
+func (s *objectSource) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	unstructuredList, ok := list.(*unstructured.UnstructuredList)
+	if !ok {
+		return fmt.Errorf("listing %T is not supported when rendering offline", list)
+	}
+	listOptions := client.ListOptions{}
+	listOptions.ApplyOptions(opts)
+
+	gvk := unstructuredList.GroupVersionKind()
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	unstructuredList.Items = nil
+	for _, object := range s.objects {
+		if object.GroupVersionKind() != gvk {
+			continue
+		}
+		if listOptions.Namespace != "" && object.GetNamespace() != listOptions.Namespace {
+			continue
+		}
+		if listOptions.LabelSelector != nil && !listOptions.LabelSelector.Matches(labels.Set(object.GetLabels())) {
+			continue
+		}
+		unstructuredList.Items = append(unstructuredList.Items, *object.DeepCopy())
+	}
+	return nil
+}
+
+// into copies object into obj, converting it if obj is typed.
+func into(object *unstructured.Unstructured, obj client.Object) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.Object = object.DeepCopy().Object
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(object.DeepCopy().Object, obj)
+}
+
+// mergeStringData merges the stringData of a Secret into its data, as the API server does when storing it.
+func mergeStringData(object *unstructured.Unstructured) error {
+	if object.GetAPIVersion() != "v1" || object.GetKind() != "Secret" {
+		return nil
+	}
+	stringData, found, err := unstructured.NestedStringMap(object.Object, "stringData")
+	if err != nil || !found {
+		return err
+	}
+
+	data, _, err := unstructured.NestedStringMap(object.Object, "data")
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = map[string]string{}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	unstructured.RemoveNestedField(object.Object, "stringData")
+	return unstructured.SetNestedStringMap(object.Object, data, "data")
+}
@@ -5,8 +5,9 @@ package generator
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,13 +19,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/retry"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -35,16 +39,35 @@ const (
 	defaultSyncPeriod = 30 * time.Second
 )
 
+// Annotations requesting a SecretTemplate to be reconciled reading its input resources from their source rather than a cache,
+// or additionally its Secret to be regenerated as if it exceeded its maximum age. Their values are opaque, usually the time of
+// the request. Each value is handled once, the last handled values are recorded in the status.
+const (
+	ReconcileRequestedAtAnnKey  = "templatedsecret.starstreak.dev/reconcile-requested-at"
+	RegenerateRequestedAtAnnKey = "templatedsecret.starstreak.dev/regenerate-requested-at"
+)
+
 // ClientLoader allows Kubernetes Clients to be loaded from a Service Account.
 type ClientLoader interface {
-	Client(ctx context.Context, saName, saNamespace string) (client.Client, error)
+	Client(ctx context.Context, sa tsv1alpha1.SecretTemplateServiceAccount, saNamespace string) (client.Client, error)
 }
 
 // Tracker allows a tracking resource to track multiple other resources
 type Tracker interface {
 	Track(tracking types.NamespacedName, tracked ...types.NamespacedName)
+	TrackSelector(tracking types.NamespacedName, namespace string, selector labels.Selector)
 	UntrackAll(tracking types.NamespacedName)
 	GetTracking(tracked types.NamespacedName) []types.NamespacedName
+	GetTrackingByLabels(namespace string, objLabels map[string]string) []types.NamespacedName
+}
+
+// FileInputs allows input resources to be read from files mounted into the controller. Files are scoped to the
+// namespace of the SecretTemplate reading them.
+type FileInputs interface {
+	Read(namespace, path string) ([]byte, error)
+	Track(tracking types.NamespacedName, paths ...string)
+	UntrackAll(tracking types.NamespacedName)
+	Events() <-chan event.GenericEvent
 }
 
 // SecretTemplateReconciler watches for SecretTemplate Resources and generates a new secret from a set of input resources.
@@ -52,12 +75,28 @@ type SecretTemplateReconciler struct {
 	mgr           manager.Manager
 	client        client.Client
 	saLoader      ClientLoader
+	apiReader     client.Reader
 	secretTracker Tracker
+	fileInputs    FileInputs
+	providers     map[string]InputProvider
+	sinks         map[string]PushSink
+	tokenIssuer   TokenIssuer
 	log           logr.Logger
 
+	// Reads input resources and existing objects instead of the clients of the controller and Service Accounts,
+	// set when rendering from objects held in memory.
+	objectSource client.Reader
+
+	// API server kubeconfigs default to
+	clusterServer string
+	clusterCA     []byte
+
 	// Reconciliation settings
 	reconciliationInterval time.Duration
 	maxSecretAge           time.Duration
+	paused                 bool
+	policiesEnabled        bool
+	encryptedConfigMaps    bool
 }
 
 var (
@@ -72,6 +111,8 @@ func NewSecretTemplateReconciler(mgr manager.Manager, client client.Client, load
 		client:                 client,
 		saLoader:               loader,
 		secretTracker:          secretTracker,
+		providers:              map[string]InputProvider{},
+		sinks:                  map[string]PushSink{},
 		log:                    log,
 		reconciliationInterval: defaultSyncPeriod,
 		maxSecretAge:           720 * time.Hour, // Default to 30 days
@@ -91,6 +132,60 @@ func (r *SecretTemplateReconciler) SetReconciliationSettings(interval, maxAge ti
 		"maxSecretAge", r.maxSecretAge.String())
 }
 
+// SetPaused suspends reconciliation of all SecretTemplates, e.g. during maintenance windows.
+func (r *SecretTemplateReconciler) SetPaused(paused bool) {
+	r.paused = paused
+}
+
+// SetPoliciesEnabled restricts the input resources SecretTemplates can read by SecretTemplatePolicies.
+// Policies are not enforced unless enabled, as their CRD may not be installed.
+func (r *SecretTemplateReconciler) SetPoliciesEnabled(enabled bool) {
+	r.policiesEnabled = enabled
+}
+
+// SetEncryptedConfigMapsEnabled allows SecretTemplates to store encrypted copies of Secrets in ConfigMaps.
+// ConfigMaps are neither watched nor written unless enabled, so the controller does not need to cache them.
+func (r *SecretTemplateReconciler) SetEncryptedConfigMapsEnabled(enabled bool) {
+	r.encryptedConfigMaps = enabled
+}
+
+// SetFileInputs allows SecretTemplates to read input resources from files. File inputs are disabled unless set.
+func (r *SecretTemplateReconciler) SetFileInputs(fileInputs FileInputs) {
+	r.fileInputs = fileInputs
+}
+
+// AddInputProvider allows SecretTemplates to read input resources of the given kind from provider.
+func (r *SecretTemplateReconciler) AddInputProvider(kind string, provider InputProvider) {
+	r.providers[kind] = provider
+}
+
+// AddPushSink allows SecretTemplates to push their data to targets of the given kind using sink.
+func (r *SecretTemplateReconciler) AddPushSink(kind string, sink PushSink) {
+	r.sinks[kind] = sink
+}
+
+// SetClusterConfig sets the API server and certificate authority kubeconfigs default to.
+func (r *SecretTemplateReconciler) SetClusterConfig(cfg *rest.Config) error {
+	caData, err := getCACert(cfg)
+	if err != nil {
+		return err
+	}
+	r.clusterServer = cfg.Host
+	r.clusterCA = caData
+	return nil
+}
+
+// SetAPIReader allows input resources to be read from the API server rather than the cache of the manager when
+// reconciliation is requested through an annotation.
+func (r *SecretTemplateReconciler) SetAPIReader(apiReader client.Reader) {
+	r.apiReader = apiReader
+}
+
+// SetTokenIssuer allows kubeconfigs to use tokens issued for the ServiceAccount of a SecretTemplate.
+func (r *SecretTemplateReconciler) SetTokenIssuer(tokenIssuer TokenIssuer) {
+	r.tokenIssuer = tokenIssuer
+}
+
 // AttachWatches adds and starts watches this reconciler requires.
 func (r *SecretTemplateReconciler) AttachWatches(c controller.Controller) error {
 	// Watch for changes to created Secrets
@@ -104,6 +199,19 @@ func (r *SecretTemplateReconciler) AttachWatches(c controller.Controller) error
 		return err
 	}
 
+	// Watch for changes to ConfigMaps holding encrypted copies of Secrets
+	if r.encryptedConfigMaps {
+		if err := c.Watch(
+			source.Kind(
+				r.mgr.GetCache(),
+				&corev1.ConfigMap{},
+				handler.TypedEnqueueRequestForOwner[*corev1.ConfigMap](r.mgr.GetScheme(), r.mgr.GetRESTMapper(), &tsv1alpha1.SecretTemplate{}, handler.OnlyControllerOwner()),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
 	err := c.Watch(
 		source.Kind(
 			r.mgr.GetCache(),
@@ -116,7 +224,16 @@ func (r *SecretTemplateReconciler) AttachWatches(c controller.Controller) error
 					Name:      a.GetName(),
 				}
 
-				for _, tracking := range r.secretTracker.GetTracking(secretKey) {
+				// SecretTemplates selecting input resources by label are also interested in Secrets they do not read yet.
+				seen := map[types.NamespacedName]bool{}
+				trackingList := append(r.secretTracker.GetTracking(secretKey), r.secretTracker.GetTrackingByLabels(a.GetNamespace(), a.GetLabels())...)
+
+				for _, tracking := range trackingList {
+					if seen[tracking] {
+						continue
+					}
+					seen[tracking] = true
+
 					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
 						Name:      tracking.Name,
 						Namespace: tracking.Namespace,
@@ -132,6 +249,26 @@ func (r *SecretTemplateReconciler) AttachWatches(c controller.Controller) error
 		return err
 	}
 
+	// Watch for changes to policies restricting input resources
+	if r.policiesEnabled {
+		if err := c.Watch(
+			source.Kind(
+				r.mgr.GetCache(),
+				&tsv1alpha1.SecretTemplatePolicy{},
+				handler.TypedEnqueueRequestsFromMapFunc(r.secretTemplatesForPolicy),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to files read as input resources
+	if r.fileInputs != nil {
+		if err := c.Watch(source.Channel(r.fileInputs.Events(), &handler.EnqueueRequestForObject{})); err != nil {
+			return err
+		}
+	}
+
 	return c.Watch(
 		source.Kind(
 			r.mgr.GetCache(),
@@ -154,6 +291,9 @@ func (r *SecretTemplateReconciler) Reconcile(ctx context.Context, request reconc
 
 			// Clear tracking if the SecretTemplate has been deleted.
 			r.secretTracker.UntrackAll(secretKey)
+			if r.fileInputs != nil {
+				r.fileInputs.UntrackAll(secretKey)
+			}
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
@@ -174,6 +314,23 @@ func (r *SecretTemplateReconciler) Reconcile(ctx context.Context, request reconc
 		UpdateFunc: func(st tsv1alpha1.GenericStatus) { secretTemplate.Status.GenericStatus = st },
 	}
 
+	if secretTemplate.Spec.Suspend || r.paused {
+		log.Info("Reconciliation is suspended")
+
+		// Input resources are tracked again once reconciliation resumes.
+		r.secretTracker.UntrackAll(secretKey)
+		if r.fileInputs != nil {
+			r.fileInputs.UntrackAll(secretKey)
+		}
+
+		if secretTemplate.Spec.Suspend {
+			status.SetSuspended(secretTemplate.ObjectMeta, "SuspendedBySpec", "reconciliation is suspended by .spec.suspend")
+		} else {
+			status.SetSuspended(secretTemplate.ObjectMeta, "ControllerPaused", "reconciliation of all SecretTemplates is paused")
+		}
+		return reconcile.Result{}, r.updateStatus(ctx, &secretTemplate)
+	}
+
 	status.SetReconciling(secretTemplate.ObjectMeta)
 	defer r.updateStatus(ctx, &secretTemplate)
 
@@ -189,7 +346,10 @@ func (r *SecretTemplateReconciler) reconcile(ctx context.Context, secretTemplate
 	}
 
 	forceRegeneration := false
+	secretExists := false
 	if err := r.client.Get(ctx, secretKey, existingSecret); err == nil {
+		secretExists = true
+
 		// Secret exists, check its age
 		if r.maxSecretAge > 0 && !existingSecret.CreationTimestamp.IsZero() {
 			age := time.Since(existingSecret.CreationTimestamp.Time)
@@ -206,13 +366,24 @@ func (r *SecretTemplateReconciler) reconcile(ctx context.Context, secretTemplate
 		return reconcile.Result{}, err
 	}
 
-	// Resolve input resources
-	inputResources, err := r.resolveInputResources(ctx, secretTemplate)
-	if err != nil {
-		return reconcile.Result{}, err
+	// Requests made through annotations are handled once. Both read input resources and tokens from their source,
+	// regeneration additionally renews generated values as if the Secret exceeded its maximum age.
+	reconcileRequest := pendingRequest(secretTemplate.Annotations[ReconcileRequestedAtAnnKey], secretTemplate.Status.LastHandledReconcileAt)
+	regenerateRequest := pendingRequest(secretTemplate.Annotations[RegenerateRequestedAtAnnKey], secretTemplate.Status.LastHandledRegenerateAt)
+	if reconcileRequest != "" || regenerateRequest != "" {
+		r.log.Info("Reconciliation requested, reading input resources from their source", "reconcileRequestedAt", reconcileRequest, "regenerateRequestedAt", regenerateRequest)
+		ctx = WithRefresh(ctx)
+	}
+	if secretExists && regenerateRequest != "" {
+		r.log.Info("Regeneration requested, forcing regeneration", "secret", existingSecret.Name)
+		forceRegeneration = true
 	}
 
-	evaluatedTemplateSecret, err := evaluateTemplate(secretTemplate.Spec.JSONPathTemplate, inputResources)
+	var existing *corev1.Secret
+	if secretExists {
+		existing = existingSecret
+	}
+	templated, err := r.render(ctx, secretTemplate, existing, forceRegeneration)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
@@ -229,36 +400,32 @@ func (r *SecretTemplateReconciler) reconcile(ctx context.Context, secretTemplate
 		},
 	}
 
-	result, err := controllerutil.CreateOrUpdate(ctx, r.client, &secret, func() error {
-		// If we need to force regeneration due to age, we need to clear the secret data first
-		if forceRegeneration {
-			secret.Data = nil
-			secret.StringData = nil
-		}
+	mutate := func(secret *corev1.Secret) error {
+		r.applyTemplate(secretTemplate, secret, templated, forceRegeneration)
+		return controllerutil.SetControllerReference(secretTemplate, secret, scheme.Scheme)
+	}
 
-		secret.Data = evaluatedTemplateSecret.Data
-		secret.StringData = evaluatedTemplateSecret.StringData
-		secret.ObjectMeta.Annotations = evaluatedTemplateSecret.Annotations
-		secret.ObjectMeta.Labels = evaluatedTemplateSecret.Labels
-		secret.TypeMeta = metav1.TypeMeta{
-			Kind:       "Secret",
-			APIVersion: "v1",
+	if secretTemplate.Spec.Preview {
+		rendered := secret
+		var current *corev1.Secret
+		if secretExists {
+			rendered = *existingSecret.DeepCopy()
+			current = existingSecret
 		}
-
-		// Secret Type is immutable in Kubernetes, so if the template's type changes and
-		// a secret already exists with a different type, we will need to delete and recreate
-		// the secret. For now, we set the type, which will work for new secrets, and log a warning
-		// if we detect a type change for existing secrets.
-		if secret.Type != "" && secret.Type != evaluatedTemplateSecret.Type {
-			r.log.Info("Warning: Secret type changes are not supported without manual deletion",
-				"secret", secret.Name,
-				"existingType", secret.Type,
-				"desiredType", evaluatedTemplateSecret.Type)
-		} else {
-			secret.Type = evaluatedTemplateSecret.Type
+		if err := mutate(&rendered); err != nil {
+			return reconcile.Result{}, err
+		}
+		secretTemplate.Status.Preview = previewSecret(current, rendered, templated.secret.Type)
+		// Regeneration is only handled once the Secret is written.
+		if reconcileRequest != "" {
+			secretTemplate.Status.LastHandledReconcileAt = reconcileRequest
 		}
+		return reconcile.Result{RequeueAfter: r.requeueAfter(secretTemplate, templated.inputs.ttl, templated.tokenRefresh)}, nil
+	}
+	secretTemplate.Status.Preview = nil
 
-		return controllerutil.SetControllerReference(secretTemplate, &secret, scheme.Scheme)
+	result, err := controllerutil.CreateOrUpdate(ctx, r.client, &secret, func() error {
+		return mutate(&secret)
 	})
 
 	if err != nil {
@@ -274,19 +441,187 @@ func (r *SecretTemplateReconciler) reconcile(ctx context.Context, secretTemplate
 
 	secretTemplate.Status.Secret.Name = secret.Name
 
+	if templated.encrypted != nil && secretTemplate.Spec.EncryptedOutput.ConfigMapName != "" {
+		if err := r.writeEncryptedConfigMap(ctx, secretTemplate, *templated.encrypted); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if len(secretTemplate.Spec.Push) > 0 {
+		if err := r.push(ctx, secretTemplate, secret); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else {
+		secretTemplate.Status.Push = nil
+	}
+
+	if reconcileRequest != "" {
+		secretTemplate.Status.LastHandledReconcileAt = reconcileRequest
+	}
+	if regenerateRequest != "" {
+		secretTemplate.Status.LastHandledRegenerateAt = regenerateRequest
+	}
+
+	return reconcile.Result{RequeueAfter: r.requeueAfter(secretTemplate, templated.inputs.ttl, templated.tokenRefresh)}, nil
+}
+
+// templatedSecret is a SecretTemplate evaluated against its input resources.
+type templatedSecret struct {
+	secret corev1.Secret
+	inputs templateValues
+	// Encrypted copy of the Secret, if requested
+	encrypted *encryptedOutput
+	// Time after which generated tokens need to be refreshed, zero if there are none
+	tokenRefresh time.Duration
+}
+
+// render resolves the input resources of secretTemplate and evaluates its template, without writing anything.
+// existing is the current Secret, if any, whose generated values are kept unless forceRegeneration is set.
+func (r *SecretTemplateReconciler) render(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, existing *corev1.Secret, forceRegeneration bool) (templatedSecret, error) {
+	// Resolve input resources
+	secretTemplate.Status.Explanation = nil
+	inputResources, absentInputResources, err := r.resolveInputResources(ctx, secretTemplate)
+	if err != nil {
+		return templatedSecret{}, err
+	}
+	secretTemplate.Status.AbsentInputResources = absentInputResources
+
+	// The explanation is reported before templating, as it is most useful when templating fails.
+	if secretTemplate.Annotations[ExplainAnnKey] == "true" {
+		secretTemplate.Status.Explanation = explainTemplate(secretTemplate.Spec.JSONPathTemplate, inputResources)
+	}
+
+	evaluatedTemplateSecret, err := evaluateTemplate(secretTemplate.Spec.JSONPathTemplate, inputResources)
+	if err != nil {
+		return templatedSecret{}, err
+	}
+
+	var tokenRefresh time.Duration
+	if len(secretTemplate.Spec.JSONPathTemplate.JWTs) > 0 {
+		current := existing
+		if forceRegeneration {
+			current = nil
+		}
+		tokens, refreshAfter, err := mintJWTs(secretTemplate.Spec.JSONPathTemplate.JWTs, inputResources, current, time.Now())
+		if err != nil {
+			return templatedSecret{}, err
+		}
+		if err := addGeneratedKeys(secretTemplate.Spec.JSONPathTemplate, &evaluatedTemplateSecret, "jwts", tokens); err != nil {
+			return templatedSecret{}, err
+		}
+		tokenRefresh = refreshAfter
+	}
+
+	if len(secretTemplate.Spec.JSONPathTemplate.Kubeconfigs) > 0 {
+		kubeconfigs, refreshAfter, err := r.kubeconfigs(ctx, secretTemplate, inputResources)
+		if err != nil {
+			return templatedSecret{}, err
+		}
+		if err := addGeneratedKeys(secretTemplate.Spec.JSONPathTemplate, &evaluatedTemplateSecret, "kubeconfigs", kubeconfigs); err != nil {
+			return templatedSecret{}, err
+		}
+		if refreshAfter > 0 && (tokenRefresh == 0 || refreshAfter < tokenRefresh) {
+			tokenRefresh = refreshAfter
+		}
+	}
+
+	explainLengths(secretTemplate.Status.Explanation, evaluatedTemplateSecret)
+
+	var encrypted *encryptedOutput
+	if secretTemplate.Spec.EncryptedOutput != nil {
+		output, err := r.encryptOutput(ctx, secretTemplate, inputResources, evaluatedTemplateSecret, existing)
+		if err != nil {
+			return templatedSecret{}, err
+		}
+		encrypted = &output
+	}
+
+	return templatedSecret{
+		secret:       evaluatedTemplateSecret,
+		inputs:       inputResources,
+		encrypted:    encrypted,
+		tokenRefresh: tokenRefresh,
+	}, nil
+}
+
+// applyTemplate updates secret, either new or the existing Secret, to hold the templated data and metadata.
+func (r *SecretTemplateReconciler) applyTemplate(secretTemplate *tsv1alpha1.SecretTemplate, secret *corev1.Secret, templated templatedSecret, forceRegeneration bool) {
+	// If we need to force regeneration due to age, we need to clear the secret data first
+	if forceRegeneration {
+		secret.Data = nil
+		secret.StringData = nil
+	}
+
+	secret.Data = templated.secret.Data
+	secret.StringData = templated.secret.StringData
+	secret.ObjectMeta.Annotations = templated.secret.Annotations
+	secret.ObjectMeta.Labels = templated.secret.Labels
+	secret.TypeMeta = metav1.TypeMeta{
+		Kind:       "Secret",
+		APIVersion: "v1",
+	}
+
+	if encrypted := templated.encrypted; encrypted != nil {
+		if secret.ObjectMeta.Annotations == nil {
+			secret.ObjectMeta.Annotations = map[string]string{}
+		}
+		secret.ObjectMeta.Annotations[encryptionRecipientsAnnotation] = encrypted.recipients
+
+		if secretTemplate.Spec.EncryptedOutput.ConfigMapName == "" {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[encrypted.key] = encrypted.ciphertext
+		}
+	}
+
+	// Secret Type is immutable in Kubernetes, so if the template's type changes and
+	// a secret already exists with a different type, we will need to delete and recreate
+	// the secret. For now, we set the type, which will work for new secrets, and log a warning
+	// if we detect a type change for existing secrets.
+	if secret.Type != "" && secret.Type != templated.secret.Type {
+		r.log.Info("Warning: Secret type changes are not supported without manual deletion",
+			"secret", secret.Name,
+			"existingType", secret.Type,
+			"desiredType", templated.secret.Type)
+	} else {
+		secret.Type = templated.secret.Type
+	}
+}
+
+// pendingRequest returns the value of a request annotation if it has not been handled yet.
+func pendingRequest(requested, lastHandled string) string {
+	if requested == lastHandled {
+		return ""
+	}
+	return requested
+}
+
+// requeueAfter returns when a SecretTemplate needs to be reconciled again, or zero if it does not need to be requeued.
+// ttl is the time until provided input resources expire, tokenRefresh the time until generated tokens need to be renewed.
+func (r *SecretTemplateReconciler) requeueAfter(secretTemplate *tsv1alpha1.SecretTemplate, ttl, tokenRefresh time.Duration) time.Duration {
+	var requeueAfter time.Duration
+
 	// Only requeue if we have a service account or max age set
 	// When using a service account, we need to periodically reconcile since we can't rely on the tracker
-	if secretTemplate.Spec.ServiceAccountName != "" {
-		return reconcile.Result{RequeueAfter: r.reconciliationInterval}, nil
+	// If max age is set, periodically requeue to check for regeneration
+	// Push targets are periodically checked for drift
+	if secretTemplate.Spec.GetServiceAccountName() != "" || r.maxSecretAge > 0 || len(secretTemplate.Spec.Push) > 0 {
+		requeueAfter = r.reconciliationInterval
 	}
 
-	// If max age is set, periodically requeue to check for regeneration
-	if r.maxSecretAge > 0 {
-		return reconcile.Result{RequeueAfter: r.reconciliationInterval}, nil
+	// Values of provided input resources are refreshed once they expire
+	if ttl > 0 && (requeueAfter == 0 || ttl < requeueAfter) {
+		requeueAfter = ttl
+	}
+
+	// JWTs and kubeconfig tokens are renewed once they pass their refresh point
+	if tokenRefresh > 0 && (requeueAfter == 0 || tokenRefresh < requeueAfter) {
+		requeueAfter = tokenRefresh
 	}
 
 	// If no service account and no max age, don't requeue - rely on resource tracking to trigger reconciliation
-	return reconcile.Result{}, nil
+	return requeueAfter
 }
 
 func (r *SecretTemplateReconciler) updateStatus(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate) error {
@@ -310,6 +645,12 @@ func (r *SecretTemplateReconciler) updateStatus(ctx context.Context, secretTempl
 		// Copy our status updates to the latest version
 		latest.Status.GenericStatus = statusUpdate.Status.GenericStatus
 		latest.Status.Secret = statusUpdate.Status.Secret
+		latest.Status.AbsentInputResources = statusUpdate.Status.AbsentInputResources
+		latest.Status.Push = statusUpdate.Status.Push
+		latest.Status.Preview = statusUpdate.Status.Preview
+		latest.Status.Explanation = statusUpdate.Status.Explanation
+		latest.Status.LastHandledReconcileAt = statusUpdate.Status.LastHandledReconcileAt
+		latest.Status.LastHandledRegenerateAt = statusUpdate.Status.LastHandledRegenerateAt
 
 		// Update status subresource
 		return r.client.Status().Update(ctx, latest)
@@ -331,6 +672,12 @@ func (r *SecretTemplateReconciler) updateStatus(ctx context.Context, secretTempl
 
 				latest.Status.GenericStatus = statusUpdate.Status.GenericStatus
 				latest.Status.Secret = statusUpdate.Status.Secret
+				latest.Status.AbsentInputResources = statusUpdate.Status.AbsentInputResources
+				latest.Status.Push = statusUpdate.Status.Push
+				latest.Status.Preview = statusUpdate.Status.Preview
+				latest.Status.Explanation = statusUpdate.Status.Explanation
+				latest.Status.LastHandledReconcileAt = statusUpdate.Status.LastHandledReconcileAt
+				latest.Status.LastHandledRegenerateAt = statusUpdate.Status.LastHandledRegenerateAt
 
 				return r.client.Update(ctx, latest)
 			})
@@ -357,10 +704,15 @@ func (r *SecretTemplateReconciler) updateStatus(ctx context.Context, secretTempl
 
 // Returns a client that was created using Service Account specified in the SecretTemplate spec.
 // If no service account was specified then it returns the same Client as used by the SecretTemplateReconciler.
-func (r *SecretTemplateReconciler) clientForSecretTemplate(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate) (client.Client, error) {
-	c := r.client
-	if secretTemplate.Spec.ServiceAccountName != "" {
-		saClient, err := r.saLoader.Client(ctx, secretTemplate.Spec.ServiceAccountName, secretTemplate.Namespace)
+// When rendering from objects held in memory, it returns the object source regardless of the Service Account.
+func (r *SecretTemplateReconciler) clientForSecretTemplate(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate) (client.Reader, error) {
+	if r.objectSource != nil {
+		return r.objectSource, nil
+	}
+
+	var c client.Reader = r.client
+	if sa := secretTemplate.Spec.GetServiceAccount(); sa != nil {
+		saClient, err := r.saLoader.Client(ctx, *sa, secretTemplate.Namespace)
 		if err != nil {
 			return nil, err
 		}
@@ -369,38 +721,164 @@ func (r *SecretTemplateReconciler) clientForSecretTemplate(ctx context.Context,
 	return c, nil
 }
 
-func (r *SecretTemplateReconciler) resolveInputResources(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate) (map[string]interface{}, error) {
-	inputResourceclient, err := r.clientForSecretTemplate(ctx, secretTemplate)
+// resolveInputResources reads all input resources of a SecretTemplate. Alongside the resolved input resources it returns
+// the names of optional input resources that do not exist.
+func (r *SecretTemplateReconciler) resolveInputResources(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate) (templateValues, []string, error) {
+	var policies []tsv1alpha1.SecretTemplatePolicy
+	if r.policiesEnabled {
+		var err error
+		policies, err = r.policiesFor(ctx, secretTemplate.Namespace)
+		if err != nil {
+			return templateValues{}, nil, err
+		}
+		if err := checkInputResourceCount(policies, secretTemplate); err != nil {
+			return templateValues{}, nil, err
+		}
+	}
+
+	inputResourceReader, err := r.clientForSecretTemplate(ctx, secretTemplate)
 	if err != nil {
-		return nil, fmt.Errorf("unable to load client for reading Input Resources: %w", err)
+		return templateValues{}, nil, fmt.Errorf("unable to load client for reading Input Resources: %w", err)
+	}
+
+	// The default client reads from the cache of the manager, Service Account clients always read from the API server.
+	if r.objectSource == nil && secretTemplate.Spec.GetServiceAccountName() == "" && r.apiReader != nil && RefreshRequested(ctx) {
+		inputResourceReader = r.apiReader
 	}
 
 	secretTemplateKey := types.NamespacedName{Namespace: secretTemplate.Namespace, Name: secretTemplate.Name}
-	resolvedInputResources := map[string]interface{}{}
+	resolvedInputResources := newTemplateValues()
+	var absentInputResources []string
 
 	// Store resources to track in a local variable to avoid a race condition in the defer function
 	var resolvedInputResourceKeys []types.NamespacedName
+	var resolvedInputResourceSelectors []labels.Selector
+	var resolvedInputFilePaths []string
 
 	// Cleanup function to ensure we track resources properly even in error cases
 	defer func() {
-		if shouldTrackInputResources(secretTemplate) {
-			// Untrack everything first in case input resources have changed
-			r.secretTracker.UntrackAll(secretTemplateKey)
-			if len(resolvedInputResourceKeys) > 0 {
-				r.secretTracker.Track(secretTemplateKey, resolvedInputResourceKeys...)
-			}
+		// Untrack everything first in case input resources have changed
+		r.secretTracker.UntrackAll(secretTemplateKey)
+		if shouldTrackInputResources(secretTemplate) && len(resolvedInputResourceKeys) > 0 {
+			r.secretTracker.Track(secretTemplateKey, resolvedInputResourceKeys...)
+		}
+		// Secrets are watched through the cache of the controller, so Secret selectors are tracked regardless of the Service Account.
+		for _, selector := range resolvedInputResourceSelectors {
+			r.secretTracker.TrackSelector(secretTemplateKey, secretTemplate.Namespace, selector)
+		}
+		// Files are read by the controller itself, so they are tracked regardless of the Service Account.
+		if r.fileInputs != nil {
+			r.fileInputs.Track(secretTemplateKey, resolvedInputFilePaths...)
 		}
 	}()
 
 	for _, inputResource := range secretTemplate.Spec.InputResources {
+		if inputSources(inputResource) != 1 {
+			return templateValues{}, nil, fmt.Errorf("unable to resolve input resource %s: exactly one of ref, file, vault, external or serviceAccountToken must be set", inputResource.Name)
+		}
+		if err := checkInputSource(policies, secretTemplate.Namespace, inputResource); err != nil {
+			return templateValues{}, nil, err
+		}
+
+		if kind := inputProviderKind(inputResource); kind != "" {
+			provider, found := r.providers[kind]
+			if !found {
+				return templateValues{}, nil, fmt.Errorf("unable to resolve input resource %s: %s inputs are not enabled", inputResource.Name, kind)
+			}
+
+			provided, err := provider.Resolve(ctx, secretTemplate, inputResource)
+			if err != nil {
+				if inputResource.Optional && isInputNotFound(err) {
+					absentInputResources = append(absentInputResources, inputResource.Name)
+					resolvedInputResources.absent[inputResource.Name] = true
+					continue
+				}
+				return templateValues{}, nil, fmt.Errorf("cannot resolve input resource %s: %w", inputResource.Name, err)
+			}
+
+			if err := resolvedInputResources.add(inputResource.Name, provided.Values); err != nil {
+				return templateValues{}, nil, err
+			}
+			if provided.TTL > 0 && (resolvedInputResources.ttl == 0 || provided.TTL < resolvedInputResources.ttl) {
+				resolvedInputResources.ttl = provided.TTL
+			}
+			continue
+		}
+
+		if inputResource.File != nil {
+			if r.fileInputs == nil {
+				return templateValues{}, nil, fmt.Errorf("unable to resolve input resource %s: file inputs are not enabled", inputResource.Name)
+			}
+
+			// Absent files are tracked as well so that the SecretTemplate is reconciled once they are created.
+			resolvedInputFilePaths = append(resolvedInputFilePaths, inputResource.File.Path)
+
+			content, err := r.fileInputs.Read(secretTemplate.Namespace, inputResource.File.Path)
+			if err != nil {
+				if inputResource.Optional && os.IsNotExist(err) {
+					absentInputResources = append(absentInputResources, inputResource.Name)
+					resolvedInputResources.absent[inputResource.Name] = true
+					continue
+				}
+				return templateValues{}, nil, fmt.Errorf("cannot read input file %s: %w", inputResource.File.Path, err)
+			}
+
+			if err := resolvedInputResources.add(inputResource.Name, map[string]interface{}{"content": string(content)}); err != nil {
+				return templateValues{}, nil, err
+			}
+			continue
+		}
+
 		// Ensure we only load Secrets if using the default Client.
-		if secretTemplate.Spec.ServiceAccountName == "" && (inputResource.Ref.Kind != "Secret" || inputResource.Ref.APIVersion != "v1") {
-			return nil, fmt.Errorf("unable to load non-secrets without a specified serviceaccount")
+		if secretTemplate.Spec.GetServiceAccountName() == "" && (inputResource.Ref.Kind != "Secret" || inputResource.Ref.APIVersion != "v1") {
+			return templateValues{}, nil, fmt.Errorf("unable to load non-secrets without a specified serviceaccount")
+		}
+
+		if (inputResource.Ref.Name == "") == (inputResource.Ref.Selector == nil) {
+			return templateValues{}, nil, fmt.Errorf("unable to resolve input resource %s: exactly one of name or selector must be set", inputResource.Name)
+		}
+
+		if inputResource.Ref.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(inputResource.Ref.Selector)
+			if err != nil {
+				return templateValues{}, nil, fmt.Errorf("unable to resolve input resource %s: %w", inputResource.Name, err)
+			}
+
+			// Secret selectors are tracked as well so that the SecretTemplate is reconciled once new Secrets match.
+			// Other kinds are not watched, newly matching resources are read on the next periodic reconciliation.
+			if inputResource.Ref.Kind == "Secret" && inputResource.Ref.APIVersion == "v1" {
+				resolvedInputResourceSelectors = append(resolvedInputResourceSelectors, selector)
+			}
+
+			items, err := listInputResources(ctx, inputResourceReader, inputResource.Ref, secretTemplate.Namespace, selector)
+			if err != nil {
+				return templateValues{}, nil, fmt.Errorf("cannot list input resource %s: %w", inputResource.Name, err)
+			}
+
+			contents := make([]map[string]interface{}, 0, len(items))
+			for _, item := range items {
+				if err := checkInputResource(policies, inputResource.Name, inputResource.Ref, secretTemplate.Namespace, item.GetName()); err != nil {
+					return templateValues{}, nil, err
+				}
+				resolvedInputResourceKeys = append(resolvedInputResourceKeys, types.NamespacedName{Namespace: item.GetNamespace(), Name: item.GetName()})
+				contents = append(contents, item.UnstructuredContent())
+			}
+
+			if err := resolvedInputResources.addList(inputResource.Name, contents); err != nil {
+				return templateValues{}, nil, err
+			}
+			continue
 		}
 
 		unstructuredResource, err := resolveInputResource(inputResource.Ref, secretTemplate.Namespace, resolvedInputResources)
 		if err != nil {
-			return nil, fmt.Errorf("unable to resolve input resource %s: %w", inputResource.Name, err)
+			// The name of an optional input resource can depend on another optional input resource that is absent.
+			if inputResource.Optional && len(absentInputResources) > 0 {
+				absentInputResources = append(absentInputResources, inputResource.Name)
+				resolvedInputResources.absent[inputResource.Name] = true
+				continue
+			}
+			return templateValues{}, nil, fmt.Errorf("unable to resolve input resource %s: %w", inputResource.Name, err)
 		}
 
 		key := types.NamespacedName{
@@ -408,53 +886,63 @@ func (r *SecretTemplateReconciler) resolveInputResources(ctx context.Context, se
 			Name:      unstructuredResource.GetName(),
 		}
 
-		if err := inputResourceclient.Get(ctx, key, &unstructuredResource); err != nil {
-			return nil, fmt.Errorf("cannot fetch input resource %s: %w", unstructuredResource.GetName(), err)
+		if err := checkInputResource(policies, inputResource.Name, inputResource.Ref, key.Namespace, key.Name); err != nil {
+			return templateValues{}, nil, err
 		}
 
-		content := unstructuredResource.UnstructuredContent()
+		// Absent input resources are tracked as well so that the SecretTemplate is reconciled once they are created.
+		resolvedInputResourceKeys = append(resolvedInputResourceKeys, key)
 
-		// If this is a Secret resource, decode base64 data fields immediately
-		if unstructuredResource.GetKind() == "Secret" && unstructuredResource.GetAPIVersion() == "v1" {
-			if data, exists := content["data"].(map[string]interface{}); exists {
-				decodedData := map[string]interface{}{}
-				for k, v := range data {
-					if strVal, ok := v.(string); ok {
-						decoded, err := base64.StdEncoding.DecodeString(strVal)
-						if err != nil {
-							return nil, fmt.Errorf("failed decoding base64 from Secret %s, data field %s: %w",
-								unstructuredResource.GetName(), k, err)
-						}
-						decodedData[k] = string(decoded)
-					}
-				}
-				// Replace the base64 encoded data with decoded values
-				content["decodedData"] = decodedData
+		if err := inputResourceReader.Get(ctx, key, &unstructuredResource); err != nil {
+			if inputResource.Optional && errors.IsNotFound(err) {
+				absentInputResources = append(absentInputResources, inputResource.Name)
+				resolvedInputResources.absent[inputResource.Name] = true
+				continue
 			}
+			return templateValues{}, nil, fmt.Errorf("cannot fetch input resource %s: %w", unstructuredResource.GetName(), err)
 		}
 
-		resolvedInputResources[inputResource.Name] = content
-		resolvedInputResourceKeys = append(resolvedInputResourceKeys, key)
+		if err := resolvedInputResources.add(inputResource.Name, unstructuredResource.UnstructuredContent()); err != nil {
+			return templateValues{}, nil, err
+		}
 	}
 
-	return resolvedInputResources, nil
+	return resolvedInputResources, absentInputResources, nil
 }
 
-func resolveInputResource(ref tsv1alpha1.InputResourceRef, namespace string, inputResources map[string]interface{}) (unstructured.Unstructured, error) {
+func resolveInputResource(ref tsv1alpha1.InputResourceRef, namespace string, inputResources templateValues) (unstructured.Unstructured, error) {
 	// Only support jsonpath for Input Resource Reference Names.
-	resolvedName, err := JSONPath(ref.Name).EvaluateWith(inputResources)
+	resolvedName, err := evaluateString(ref.Name, inputResources)
 	if err != nil {
 		return unstructured.Unstructured{}, err
 	}
 
-	return toUnstructured(ref.APIVersion, ref.Kind, namespace, resolvedName.String())
+	return toUnstructured(ref.APIVersion, ref.Kind, namespace, resolvedName)
+}
+
+// listInputResources lists all resources of the kind referred to in namespace matching selector, sorted by name
+// so that templates render the same regardless of the order resources are returned in.
+func listInputResources(ctx context.Context, c client.Reader, ref tsv1alpha1.InputResourceRef, namespace string, selector labels.Selector) ([]unstructured.Unstructured, error) {
+	list, err := toUnstructured(ref.APIVersion, ref.Kind+"List", namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	unstructuredList := unstructured.UnstructuredList{Object: list.Object}
+	if err := c.List(ctx, &unstructuredList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	items := unstructuredList.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].GetName() < items[j].GetName() })
+	return items, nil
 }
 
 // Returns whether we should track the resources contained in a SecretTemplate.
 // We only track resources when a ServiceAccountName has not been specified. This implicitly means
 // we only track Secret resources.
 func shouldTrackInputResources(s *tsv1alpha1.SecretTemplate) bool {
-	return s.Spec.ServiceAccountName == ""
+	return s.Spec.GetServiceAccountName() == ""
 }
 
 func toUnstructured(apiVersion, kind, namespace, name string) (unstructured.Unstructured, error) {
@@ -477,7 +965,7 @@ func toUnstructured(apiVersion, kind, namespace, name string) (unstructured.Unst
 	return obj, nil
 }
 
-func evaluateTemplate(template *tsv1alpha1.JSONPathTemplate, values map[string]interface{}) (corev1.Secret, error) {
+func evaluateTemplate(template *tsv1alpha1.JSONPathTemplate, values templateValues) (corev1.Secret, error) {
 	// Check if template is nil to prevent panic
 	if template == nil {
 		return corev1.Secret{}, fmt.Errorf("JSONPathTemplate is nil")
@@ -495,6 +983,34 @@ func evaluateTemplate(template *tsv1alpha1.JSONPathTemplate, values map[string]i
 		return corev1.Secret{}, fmt.Errorf("templating stringData: %w", err)
 	}
 
+	// Template Secret URIs
+	uris, err := evaluateURIs(template.URIs, values)
+	if err != nil {
+		return corev1.Secret{}, fmt.Errorf("templating uris: %w", err)
+	}
+	for key, uri := range uris {
+		if _, found := data[key]; found {
+			return corev1.Secret{}, fmt.Errorf("templating uris: key %s is also defined in data", key)
+		}
+		if _, found := stringData[key]; found {
+			return corev1.Secret{}, fmt.Errorf("templating uris: key %s is also defined in stringData", key)
+		}
+		stringData[key] = uri
+	}
+
+	// Template Secret DataFrom
+	dataFrom, err := evaluateDataFrom(template.DataFrom, values)
+	if err != nil {
+		return corev1.Secret{}, fmt.Errorf("templating dataFrom: %w", err)
+	}
+	for key, value := range dataFrom {
+		_, inData := data[key]
+		_, inStringData := stringData[key]
+		if !inData && !inStringData {
+			data[key] = value
+		}
+	}
+
 	// Template Secret Annotations
 	annotations, err := evaluate(template.Metadata.Annotations, values)
 	if err != nil {
@@ -508,7 +1024,7 @@ func evaluateTemplate(template *tsv1alpha1.JSONPathTemplate, values map[string]i
 	}
 
 	// Template Secret Type
-	typeBuffer, err := JSONPath(template.Type).EvaluateWith(values)
+	secretType, err := evaluateString(string(template.Type), values)
 	if err != nil {
 		return corev1.Secret{}, fmt.Errorf("templating type: %w", err)
 	}
@@ -518,69 +1034,62 @@ func evaluateTemplate(template *tsv1alpha1.JSONPathTemplate, values map[string]i
 			Labels:      labels,
 			Annotations: annotations,
 		},
-		Type:       corev1.SecretType(typeBuffer.String()),
+		Type:       corev1.SecretType(secretType),
 		StringData: stringData,
 		Data:       data,
 	}, nil
 }
 
-func evaluate(mapping map[string]string, values map[string]interface{}) (map[string]string, error) {
+func evaluate(mapping map[string]string, values templateValues) (map[string]string, error) {
 	evaluatedMapping := map[string]string{}
 	for key, expression := range mapping {
-		valueBuffer, err := JSONPath(expression).EvaluateWith(values)
+		value, err := evaluateString(expression, values)
 		if err != nil {
 			return nil, err
 		}
-
-		exprStr := expression
-		if strings.Contains(exprStr, ".data.") {
-			// For paths like $(.resourceName.data.key), try using the already decoded data first
-			decodedExpr := strings.Replace(exprStr, ".data.", ".decodedData.", 1)
-			decodedValueBuffer, decodedErr := JSONPath(decodedExpr).EvaluateWith(values)
-			if decodedErr == nil {
-				// Successfully found data in decodedData, use it directly
-				evaluatedMapping[key] = decodedValueBuffer.String()
-				continue
-			}
-		}
-
-		evaluatedMapping[key] = valueBuffer.String()
+		evaluatedMapping[key] = value
 	}
 
 	return evaluatedMapping, nil
 }
 
-func evaluateBytes(mapping map[string]string, values map[string]interface{}) (map[string][]byte, error) {
+func evaluateBytes(mapping map[string]string, values templateValues) (map[string][]byte, error) {
 	evaluatedMapping := map[string][]byte{}
 	for key, expression := range mapping {
-		valueBuffer, err := JSONPath(expression).EvaluateWith(values)
+		value, err := evaluateData(expression, values)
 		if err != nil {
 			return nil, err
 		}
+		evaluatedMapping[key] = value
+	}
 
-		// Check if the expression refers to a path that might include decodedData
-		// If it references a path that looks like .resourceName.data.field, try to convert it to use
-		// decodedData instead to avoid decoding again
-		exprStr := expression
-		if strings.Contains(exprStr, ".data.") {
-			// For paths like $(.resourceName.data.key), try using the already decoded data first
-			decodedExpr := strings.Replace(exprStr, ".data.", ".decodedData.", 1)
-			decodedValueBuffer, decodedErr := JSONPath(decodedExpr).EvaluateWith(values)
-			if decodedErr == nil {
-				// Successfully found data in decodedData, use it directly
-				evaluatedMapping[key] = decodedValueBuffer.Bytes()
-				continue
-			}
-		}
+	return evaluatedMapping, nil
+}
 
-		// Fall back to the original behavior if decodedData approach doesn't work
-		decoded, err := base64.StdEncoding.DecodeString(valueBuffer.String())
-		if err != nil {
-			return nil, fmt.Errorf("failed decoding base64 from a Secret: %w", err)
+// addGeneratedKeys adds keys generated by the controller, e.g. JWTs, to the stringData of secret.
+// Keys copied by dataFrom are overridden, keys defined in the template are not.
+func addGeneratedKeys(template *tsv1alpha1.JSONPathTemplate, secret *corev1.Secret, field string, generated map[string]string) error {
+	for key, value := range generated {
+		if _, found := template.Data[key]; found {
+			return fmt.Errorf("templating %s: key %s is also defined in data", field, key)
 		}
-
-		evaluatedMapping[key] = decoded
+		if _, found := secret.StringData[key]; found {
+			return fmt.Errorf("templating %s: key %s is already defined", field, key)
+		}
+		delete(secret.Data, key)
+		secret.StringData[key] = value
 	}
+	return nil
+}
 
-	return evaluatedMapping, nil
+// secretData returns the keys of a Secret as they are stored, stringData taking precedence over data.
+func secretData(secret corev1.Secret) map[string][]byte {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+	return data
 }
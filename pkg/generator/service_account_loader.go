@@ -6,69 +6,294 @@ package generator
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
 	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 	certutil "k8s.io/client-go/util/cert"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	ctrl "sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
+// impersonatedClientIdleTimeout is how long the client of an impersonated Service Account is kept after its last use.
+const impersonatedClientIdleTimeout = time.Hour
+
 // TokenManager handles getting a valid token for a given ServiceAccount.
 type TokenManager interface {
 	GetServiceAccountToken(ctx context.Context, namespace, name string, tr *authv1.TokenRequest) (*authv1.TokenRequest, error)
 }
 
+// TokenIssuer issues tokens of Service Accounts that SecretTemplates write into Secrets, such as those of kubeconfigs.
+// Unlike tokens used by the controller itself, issued tokens leave the cluster's control, so issuers ensure the Service
+// Account of the SecretTemplate is allowed to create them and bound their lifetime.
+type TokenIssuer interface {
+	IssueToken(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, serviceAccount string, tr *authv1.TokenRequest) (*authv1.TokenRequest, error)
+}
+
+// TokenSettings are the lifetime and audiences of the tokens the controller requests for Service Accounts of
+// SecretTemplates, which the Service Account of a SecretTemplate may override.
+type TokenSettings struct {
+	// Lifetime of tokens. Defaults to one hour.
+	Expiration time.Duration
+	// Audiences of tokens. Defaults to the audiences of the API server.
+	Audiences []string
+}
+
+// TokenRequest returns the request for a token of sa, defaulting to the audiences and lifetime of the settings.
+func (t TokenSettings) TokenRequest(sa tsv1alpha1.SecretTemplateServiceAccount) *authv1.TokenRequest {
+	audiences := t.Audiences
+	if len(sa.Audiences) > 0 {
+		audiences = sa.Audiences
+	}
+	expiration := int64(time.Hour.Seconds())
+	if t.Expiration > 0 {
+		expiration = int64(t.Expiration.Seconds())
+	}
+	if sa.ExpirationSeconds != nil {
+		expiration = *sa.ExpirationSeconds
+	}
+	return &authv1.TokenRequest{
+		Spec: authv1.TokenRequestSpec{
+			Audiences:         audiences,
+			ExpirationSeconds: &expiration,
+		},
+	}
+}
+
+// RejectedTokenReviewer is optionally implemented by TokenManagers to review tokens the API server rejected, so that
+// tokens which are no longer valid are requested again rather than served from a cache.
+type RejectedTokenReviewer interface {
+	RejectedToken(ctx context.Context, namespace, name string, tr *authv1.TokenRequest)
+}
+
 // ServiceAccountLoader allows the construction of a k8s client from a Service Account
 type ServiceAccountLoader struct {
 	// Ensures a valid token for a ServiceAccount is available.
 	tokenManager TokenManager
+
+	// Config clients are derived from. Defaults to the config of the controller.
+	config *rest.Config
+
+	// Lifetime and audiences of the tokens requested for Service Accounts.
+	tokenSettings TokenSettings
+
+	// Reads Service Accounts when they are impersonated rather than authenticated with a token, nil otherwise.
+	serviceAccounts client.Reader
+
+	// mutex guards the fields below, which are set up on first use.
+	mutex sync.Mutex
+	// Config of the clients of all Service Accounts, without credentials of the controller when authenticating with tokens.
+	clientConfig *rest.Config
+	// Transport shared by the clients of all Service Accounts, adding their credentials to requests.
+	httpClient *http.Client
+	// REST mapper shared by the clients of all Service Accounts, discovering API groups as they are first used.
+	mapper meta.RESTMapper
+	// Clients by Service Account and token settings, along with the token they were created with. Clients are evicted
+	// once their token expired, or once unused for impersonatedClientIdleTimeout when impersonating.
+	clients map[clientKey]cachedClient
+}
+
+// clientKey identifies the client of a Service Account. Tokens requested with different audiences or lifetimes are
+// not interchangeable, so each of them has its own client.
+type clientKey struct {
+	types.NamespacedName
+	audiences         string
+	expirationSeconds int64
+}
+
+type cachedClient struct {
+	token   string
+	expires time.Time
+	client  client.Client
 }
 
 // NewServiceAccountLoader creates a new ServiceAccountLoader
 func NewServiceAccountLoader(manager TokenManager) *ServiceAccountLoader {
-	return &ServiceAccountLoader{manager}
+	return &ServiceAccountLoader{tokenManager: manager}
 }
 
-// Client returns a new k8s client for a Service Account
-func (s *ServiceAccountLoader) Client(ctx context.Context, saName, saNamespace string) (client.Client, error) {
-	config, err := s.restConfig(ctx, saName, saNamespace)
-	if err != nil {
-		return nil, err
-	}
+// NewImpersonatingServiceAccountLoader creates a ServiceAccountLoader impersonating Service Accounts with the credentials
+// of cfg instead of requesting tokens for them. Service Accounts are read using serviceAccounts, as impersonating a
+// Service Account that does not exist would succeed.
+func NewImpersonatingServiceAccountLoader(cfg *rest.Config, serviceAccounts client.Reader) *ServiceAccountLoader {
+	return &ServiceAccountLoader{config: cfg, serviceAccounts: serviceAccounts}
+}
 
-	return client.New(config, client.Options{})
+// SetConfig sets the config clients are derived from, e.g. the config the controller was started with.
+func (s *ServiceAccountLoader) SetConfig(cfg *rest.Config) {
+	s.config = cfg
 }
 
-func (s *ServiceAccountLoader) restConfig(ctx context.Context, saName, saNamespace string) (*rest.Config, error) {
-	cfg, err := ctrl.GetConfig()
+// SetTokenSettings configures the lifetime and audiences of the tokens requested for Service Accounts. Tokens default to
+// a lifetime of one hour and the audiences of the API server. SecretTemplates can override both for their Service Account.
+func (s *ServiceAccountLoader) SetTokenSettings(settings TokenSettings) {
+	s.tokenSettings = settings
+}
+
+// Client returns a k8s client for a Service Account. Tokens are requested with the audiences and lifetime of sa, if set.
+// Clients are reused until the token of the Service Account rotates or expires.
+func (s *ServiceAccountLoader) Client(ctx context.Context, sa tsv1alpha1.SecretTemplateServiceAccount, saNamespace string) (client.Client, error) {
+	config, httpClient, mapper, err := s.shared()
 	if err != nil {
 		return nil, err
 	}
 
-	expiration := int64(time.Hour.Seconds())
-	tokenRequest, err := s.tokenManager.GetServiceAccountToken(ctx, saNamespace, saName, &authv1.TokenRequest{
-		Spec: authv1.TokenRequestSpec{
-			ExpirationSeconds: &expiration,
-		},
+	saName := sa.Name
+	key := clientKey{NamespacedName: types.NamespacedName{Namespace: saNamespace, Name: saName}}
+
+	var token string
+	var expires time.Time
+	var roundTripper http.RoundTripper
+	if s.serviceAccounts != nil {
+		impersonate, err := s.impersonationConfig(ctx, saName, saNamespace)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = transport.NewImpersonatingRoundTripper(impersonate, httpClient.Transport)
+		expires = time.Now().Add(impersonatedClientIdleTimeout)
+	} else {
+		tokenRequest := s.tokenSettings.TokenRequest(sa)
+		key.audiences = strings.Join(tokenRequest.Spec.Audiences, ",")
+		key.expirationSeconds = *tokenRequest.Spec.ExpirationSeconds
+
+		token, expires, err = s.token(ctx, saName, saNamespace, tokenRequest)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = transport.NewBearerAuthRoundTripper(token, httpClient.Transport)
+
+		if reviewer, ok := s.tokenManager.(RejectedTokenReviewer); ok {
+			roundTripper = &rejectedTokenRoundTripper{delegate: roundTripper, rejected: func(ctx context.Context) {
+				reviewer.RejectedToken(ctx, saNamespace, saName, tokenRequest)
+			}}
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if cached, found := s.clients[key]; found && cached.token == token && time.Now().Before(cached.expires) {
+		if s.serviceAccounts != nil {
+			cached.expires = expires
+			s.clients[key] = cached
+		}
+		return cached.client, nil
+	}
+	s.evictExpiredClients()
+
+	c, err := client.New(config, client.Options{
+		HTTPClient: &http.Client{Transport: roundTripper, Timeout: httpClient.Timeout},
+		Mapper:     mapper,
 	})
 	if err != nil {
 		return nil, err
 	}
+	s.clients[key] = cachedClient{token: token, expires: expires, client: c}
+	return c, nil
+}
+
+// evictExpiredClients removes clients whose token expired, such as those of Service Accounts that were deleted or of
+// token settings no longer used by any SecretTemplate. The caller must hold the mutex.
+func (s *ServiceAccountLoader) evictExpiredClients() {
+	now := time.Now()
+	for key, cached := range s.clients {
+		if !now.Before(cached.expires) {
+			delete(s.clients, key)
+		}
+	}
+}
+
+// shared returns the config, transport and REST mapper shared by the clients of all Service Accounts, setting them up
+// on first use.
+func (s *ServiceAccountLoader) shared() (*rest.Config, *http.Client, meta.RESTMapper, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.config == nil {
+		cfg, err := ctrl.GetConfig()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		s.config = cfg
+	}
+	if s.clientConfig != nil {
+		return s.clientConfig, s.httpClient, s.mapper, nil
+	}
+
+	clientConfig := s.config
+	if s.serviceAccounts == nil {
+		clientConfig = rest.AnonymousClientConfig(s.config)
+	}
+	httpClient, err := rest.HTTPClientFor(clientConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	caData, err := getCACert(cfg)
+	// APIs are discovered with the credentials of the controller, as the Service Accounts of SecretTemplates may not be allowed to.
+	mapperClient, err := rest.HTTPClientFor(s.config)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+	mapper, err := apiutil.NewDynamicRESTMapper(s.config, mapperClient)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return &rest.Config{
-		Host: cfg.Host,
-		TLSClientConfig: rest.TLSClientConfig{
-			CAData: caData,
-		},
-		BearerToken: tokenRequest.Status.Token,
+	s.clientConfig = clientConfig
+	s.httpClient = httpClient
+	s.mapper = mapper
+	s.clients = map[clientKey]cachedClient{}
+	return clientConfig, httpClient, mapper, nil
+}
+
+// token returns a token of a Service Account along with its expiry, which is derived from the requested lifetime if
+// the token manager does not report it.
+func (s *ServiceAccountLoader) token(ctx context.Context, saName, saNamespace string, tr *authv1.TokenRequest) (string, time.Time, error) {
+	// The request is kept to review the token should it be rejected, so the token manager is passed a copy.
+	tokenRequest, err := s.tokenManager.GetServiceAccountToken(ctx, saNamespace, saName, tr.DeepCopy())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := tokenRequest.Status.ExpirationTimestamp.Time
+	if expires.IsZero() {
+		expires = time.Now().Add(time.Duration(*tr.Spec.ExpirationSeconds) * time.Second)
+	}
+	return tokenRequest.Status.Token, expires, nil
+}
+
+// rejectedTokenRoundTripper reports responses of the API server rejecting the token of a request.
+type rejectedTokenRoundTripper struct {
+	delegate http.RoundTripper
+	rejected func(ctx context.Context)
+}
+
+func (rt *rejectedTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.delegate.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.rejected(req.Context())
+	}
+	return resp, err
+}
+
+// impersonationConfig returns the impersonation of a Service Account. Only the user is impersonated: the API server
+// adds the groups of the Service Account itself, so the controller never needs to impersonate groups.
+func (s *ServiceAccountLoader) impersonationConfig(ctx context.Context, saName, saNamespace string) (transport.ImpersonationConfig, error) {
+	if err := s.serviceAccounts.Get(ctx, types.NamespacedName{Namespace: saNamespace, Name: saName}, &corev1.ServiceAccount{}); err != nil {
+		return transport.ImpersonationConfig{}, fmt.Errorf("fetching service account %s/%s: %w", saNamespace, saName, err)
+	}
+
+	return transport.ImpersonationConfig{
+		UserName: fmt.Sprintf("system:serviceaccount:%s:%s", saNamespace, saName),
 	}, nil
 }
 
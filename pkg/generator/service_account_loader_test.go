@@ -5,31 +5,67 @@ package generator_test
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
 	"github.com/drae/templated-secret-controller/pkg/generator"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 // SimpleTokenManager implements the TokenManager interface for testing
 type SimpleTokenManager struct {
 	Token string
 	Err   error
+	// Expires is reported as the expiry of the token, if set
+	Expires time.Time
+
+	// Requests records the specs of the token requests
+	Requests []authv1.TokenRequestSpec
 }
 
 func (m *SimpleTokenManager) GetServiceAccountToken(ctx context.Context, namespace, name string, tr *authv1.TokenRequest) (*authv1.TokenRequest, error) {
+	m.Requests = append(m.Requests, tr.Spec)
 	if m.Err != nil {
 		return nil, m.Err
 	}
 
 	return &authv1.TokenRequest{
 		Status: authv1.TokenRequestStatus{
-			Token: m.Token,
+			Token:               m.Token,
+			ExpirationTimestamp: metav1.NewTime(m.Expires),
 		},
 	}, nil
 }
 
+// rejectedTokenManager records the token requests of tokens rejected by the API server.
+type rejectedTokenManager struct {
+	SimpleTokenManager
+	rejected []*authv1.TokenRequest
+}
+
+func (m *rejectedTokenManager) RejectedToken(_ context.Context, _, _ string, tr *authv1.TokenRequest) {
+	m.rejected = append(m.rejected, tr)
+}
+
 // Test_NewServiceAccountLoader verifies that the constructor correctly initializes the loader
 func Test_NewServiceAccountLoader(t *testing.T) {
 	// Create a simple token manager
@@ -42,5 +78,295 @@ func Test_NewServiceAccountLoader(t *testing.T) {
 	assert.NotNil(t, loader)
 }
 
-// Additional tests would need to be implemented using a more sophisticated
-// mocking approach that can handle controller-runtime clients and configs
+// Test_ServiceAccountLoader_AccessChecks verifies that clients impersonating a Service Account are granted the same
+// access as clients using its token.
+func Test_ServiceAccountLoader_AccessChecks(t *testing.T) {
+	server, _ := newFakeAPIServer(t)
+	baseConfig := fakeAPIServerConfig(server)
+
+	tokenLoader := generator.NewServiceAccountLoader(&SimpleTokenManager{Token: serviceAccountToken})
+	tokenLoader.SetConfig(baseConfig)
+
+	serviceAccounts := fakeClient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "app"},
+	}).Build()
+	impersonatingLoader := generator.NewImpersonatingServiceAccountLoader(baseConfig, serviceAccounts)
+
+	loaders := map[string]generator.ClientLoader{
+		"token":         tokenLoader,
+		"impersonation": impersonatingLoader,
+	}
+
+	for name, loader := range loaders {
+		t.Run(name, func(t *testing.T) {
+			c, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+			require.NoError(t, err)
+
+			var allowed corev1.ConfigMap
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "app", Name: "allowed"}, &allowed))
+			assert.Equal(t, "allowed", allowed.Name)
+
+			err = c.Get(context.Background(), types.NamespacedName{Namespace: "app", Name: "denied"}, &corev1.ConfigMap{})
+			assert.True(t, errors.IsForbidden(err), "expected forbidden, got %v", err)
+		})
+	}
+
+	t.Run("impersonating an absent service account", func(t *testing.T) {
+		_, err := impersonatingLoader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "absent"}, "app")
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err))
+	})
+}
+
+// Test_ServiceAccountLoader_ClientCache verifies that clients are reused until the token of their Service Account rotates.
+func Test_ServiceAccountLoader_ClientCache(t *testing.T) {
+	server, _ := newFakeAPIServer(t)
+
+	tokenManager := &SimpleTokenManager{Token: serviceAccountToken}
+	loader := generator.NewServiceAccountLoader(tokenManager)
+	loader.SetConfig(fakeAPIServerConfig(server))
+
+	first, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+
+	second, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	other, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "other"}, "app")
+	require.NoError(t, err)
+	assert.NotSame(t, first, other)
+
+	// A rotated token results in a new client, sharing the REST mapper of the previous one.
+	tokenManager.Token = "rotated-token"
+	rotated, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+	assert.NotSame(t, first, rotated)
+	assert.Same(t, first.RESTMapper(), rotated.RESTMapper())
+
+	err = rotated.Get(context.Background(), types.NamespacedName{Namespace: "app", Name: "allowed"}, &corev1.ConfigMap{})
+	assert.True(t, errors.IsUnauthorized(err), "expected unauthorized, got %v", err)
+
+	// Clients are not reused once their token expired, even if the token manager still serves it.
+	tokenManager.Token = "expired-token"
+	tokenManager.Expires = time.Now().Add(-time.Minute)
+	expired, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+
+	renewed, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+	assert.NotSame(t, expired, renewed)
+}
+
+// Test_ServiceAccountLoader_TokenSettings verifies that the token settings of a SecretTemplate's Service Account override
+// the defaults of the loader, and that tokens with different settings are not used interchangeably.
+func Test_ServiceAccountLoader_TokenSettings(t *testing.T) {
+	server, _ := newFakeAPIServer(t)
+
+	tokenManager := &SimpleTokenManager{Token: serviceAccountToken}
+	loader := generator.NewServiceAccountLoader(tokenManager)
+	loader.SetConfig(fakeAPIServerConfig(server))
+	loader.SetTokenSettings(generator.TokenSettings{Expiration: 2 * time.Hour, Audiences: []string{"https://kubernetes.default.svc"}})
+
+	defaults, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+
+	expiration := int64(600)
+	custom := tsv1alpha1.SecretTemplateServiceAccount{Name: "reader", Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &expiration}
+	first, err := loader.Client(context.Background(), custom, "app")
+	require.NoError(t, err)
+	assert.NotSame(t, defaults, first)
+
+	second, err := loader.Client(context.Background(), custom, "app")
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	defaultExpiration := int64(7200)
+	assert.Equal(t, []authv1.TokenRequestSpec{
+		{Audiences: []string{"https://kubernetes.default.svc"}, ExpirationSeconds: &defaultExpiration},
+		{Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &expiration},
+		{Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &expiration},
+	}, tokenManager.Requests)
+}
+
+func Test_TokenSettings(t *testing.T) {
+	hour, tenMinutes := int64(3600), int64(600)
+
+	tests := []struct {
+		name     string
+		settings generator.TokenSettings
+		sa       tsv1alpha1.SecretTemplateServiceAccount
+		expected authv1.TokenRequestSpec
+	}{
+		{
+			name:     "defaults to one hour and the audiences of the API server",
+			expected: authv1.TokenRequestSpec{ExpirationSeconds: &hour},
+		},
+		{
+			name:     "uses the settings of the controller",
+			settings: generator.TokenSettings{Expiration: 10 * time.Minute, Audiences: []string{"https://kubernetes.default.svc"}},
+			expected: authv1.TokenRequestSpec{Audiences: []string{"https://kubernetes.default.svc"}, ExpirationSeconds: &tenMinutes},
+		},
+		{
+			name:     "uses the settings of the service account",
+			settings: generator.TokenSettings{Expiration: 2 * time.Hour, Audiences: []string{"https://kubernetes.default.svc"}},
+			sa:       tsv1alpha1.SecretTemplateServiceAccount{Name: "reader", Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &hour},
+			expected: authv1.TokenRequestSpec{Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &hour},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.settings.TokenRequest(tc.sa).Spec)
+		})
+	}
+}
+
+// Test_ServiceAccountLoader_RejectedToken verifies that tokens rejected by the API server are reported to the token manager.
+func Test_ServiceAccountLoader_RejectedToken(t *testing.T) {
+	server, _ := newFakeAPIServer(t)
+
+	tokenManager := &rejectedTokenManager{SimpleTokenManager: SimpleTokenManager{Token: "revoked-token"}}
+	loader := generator.NewServiceAccountLoader(tokenManager)
+	loader.SetConfig(fakeAPIServerConfig(server))
+	loader.SetTokenSettings(generator.TokenSettings{Expiration: 2 * time.Hour, Audiences: []string{"https://kubernetes.default.svc"}})
+
+	c, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+	require.NoError(t, err)
+
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "app", Name: "allowed"}, &corev1.ConfigMap{})
+	assert.True(t, errors.IsUnauthorized(err), "expected unauthorized, got %v", err)
+
+	require.Len(t, tokenManager.rejected, 1)
+	assert.Equal(t, []string{"https://kubernetes.default.svc"}, tokenManager.rejected[0].Spec.Audiences)
+	assert.Equal(t, int64(7200), *tokenManager.rejected[0].Spec.ExpirationSeconds)
+}
+
+// Benchmark_ServiceAccountLoader_Client compares the API requests of reading an input resource with a client created
+// for every reconciliation, which discovers the APIs of the server every time, to a client of the ServiceAccountLoader.
+func Benchmark_ServiceAccountLoader_Client(b *testing.B) {
+	readInput := func(b *testing.B, c client.Client) {
+		require.NoError(b, c.Get(context.Background(), types.NamespacedName{Namespace: "app", Name: "allowed"}, &corev1.ConfigMap{}))
+	}
+
+	b.Run("client per reconcile", func(b *testing.B) {
+		server, requests := newFakeAPIServer(b)
+		cfg := fakeAPIServerConfig(server)
+		cfg.BearerToken = serviceAccountToken
+
+		for i := 0; i < b.N; i++ {
+			c, err := client.New(cfg, client.Options{})
+			require.NoError(b, err)
+			readInput(b, c)
+		}
+		b.ReportMetric(float64(requests.Load())/float64(b.N), "requests/op")
+	})
+
+	b.Run("cached client", func(b *testing.B) {
+		server, requests := newFakeAPIServer(b)
+		loader := generator.NewServiceAccountLoader(&SimpleTokenManager{Token: serviceAccountToken})
+		loader.SetConfig(fakeAPIServerConfig(server))
+
+		for i := 0; i < b.N; i++ {
+			c, err := loader.Client(context.Background(), tsv1alpha1.SecretTemplateServiceAccount{Name: "reader"}, "app")
+			require.NoError(b, err)
+			readInput(b, c)
+		}
+		b.ReportMetric(float64(requests.Load())/float64(b.N), "requests/op")
+	})
+}
+
+const (
+	controllerToken     = "controller-token"
+	serviceAccountToken = "reader-token"
+	serviceAccountUser  = "system:serviceaccount:app:reader"
+	controllerUser      = "system:serviceaccount:templated-secret:templated-secret-controller"
+)
+
+// newFakeAPIServer serves ConfigMaps of the app namespace, of which the reader Service Account may only get the one
+// named allowed. The Service Account is authenticated either by its token, or by the controller impersonating it, in
+// which case its groups are derived like the API server does when no groups are impersonated. APIs can be discovered by any user. Requests served are counted.
+func newFakeAPIServer(t testing.TB) (*httptest.Server, *atomic.Int64) {
+	var requests atomic.Int64
+
+	writeJSON := func(w http.ResponseWriter, code int, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		require.NoError(t, json.NewEncoder(w).Encode(obj))
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		var user string
+		var groups []string
+		switch strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		case serviceAccountToken:
+			user = serviceAccountUser
+			groups = []string{"system:serviceaccounts", "system:serviceaccounts:app"}
+		case controllerToken:
+			user = controllerUser
+			if impersonated := r.Header.Get("Impersonate-User"); impersonated != "" {
+				user = impersonated
+				groups = r.Header.Values("Impersonate-Group")
+				if parts := strings.Split(impersonated, ":"); len(groups) == 0 && len(parts) == 4 && parts[0] == "system" && parts[1] == "serviceaccount" {
+					groups = []string{"system:serviceaccounts", "system:serviceaccounts:" + parts[2]}
+				}
+			}
+		}
+		if user == "" {
+			writeJSON(w, http.StatusUnauthorized, metav1.Status{
+				TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   metav1.StatusFailure,
+				Reason:   metav1.StatusReasonUnauthorized,
+				Code:     http.StatusUnauthorized,
+			})
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api":
+			writeJSON(w, http.StatusOK, metav1.APIVersions{TypeMeta: metav1.TypeMeta{Kind: "APIVersions"}, Versions: []string{"v1"}})
+		case "/apis":
+			writeJSON(w, http.StatusOK, metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}})
+		case "/api/v1":
+			writeJSON(w, http.StatusOK, metav1.APIResourceList{
+				TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"get"}}},
+			})
+		case "/api/v1/namespaces/app/configmaps/allowed", "/api/v1/namespaces/app/configmaps/denied":
+			name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			// RBAC rules may refer to the Service Account by name or by group.
+			if user != serviceAccountUser || !slices.Contains(groups, "system:serviceaccounts:app") || name != "allowed" {
+				writeJSON(w, http.StatusForbidden, metav1.Status{
+					TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+					Status:   metav1.StatusFailure,
+					Reason:   metav1.StatusReasonForbidden,
+					Code:     http.StatusForbidden,
+					Message:  fmt.Sprintf("configmaps %q is forbidden: User %q cannot get resource \"configmaps\"", name, user),
+				})
+				return
+			}
+			writeJSON(w, http.StatusOK, corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// fakeAPIServerConfig returns the config of the controller for a fake API server. Client-side rate limiting is disabled,
+// as cached clients share their rate limiter across reconciliations.
+func fakeAPIServerConfig(server *httptest.Server) *rest.Config {
+	return &rest.Config{
+		Host:            server.URL,
+		BearerToken:     controllerToken,
+		TLSClientConfig: rest.TLSClientConfig{CAData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})},
+		QPS:             -1,
+	}
+}
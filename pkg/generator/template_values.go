@@ -0,0 +1,171 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// encodedFields lists the top level fields of well known resources whose values are base64 encoded.
+// Values read from these fields are decoded before they are templated.
+var encodedFields = map[schema.GroupVersionKind][]string{
+	{Version: "v1", Kind: "Secret"}:    {"data"},
+	{Version: "v1", Kind: "ConfigMap"}: {"binaryData"},
+}
+
+// dataFields lists the top level fields holding the keys of resources that can be copied into a Secret using dataFrom.
+var dataFields = map[schema.GroupVersionKind][]string{
+	{Version: "v1", Kind: "Secret"}:    {"data"},
+	{Version: "v1", Kind: "ConfigMap"}: {"data", "binaryData"},
+}
+
+// Matches the input name and top level field a JSONPath starts with, e.g. ".creds.data.password".
+// Input resources selected by a label selector are indexed, e.g. ".creds[*].data.password".
+var inputFieldPath = regexp.MustCompile(`^\.((?:\\.|[^.\[\]\s\\])+)(?:\[[^\]]*\])?\.((?:\\.|[^.\[\]\s\\])+)`)
+
+// Matches the input name a JSONPath starts with, e.g. "creds" in ".creds.data.password".
+var inputNamePath = regexp.MustCompile(`^\.((?:\\.|[^.\[\]\s\\])+)`)
+
+// templateValues are the resolved input resources expressions are evaluated against.
+type templateValues struct {
+	// inputs holds input resources as they were read.
+	inputs map[string]interface{}
+	// decoded holds input resources with their base64 encoded fields decoded.
+	decoded map[string]interface{}
+	// encoded holds the names of the base64 encoded fields of each input resource.
+	encoded map[string]map[string]bool
+	// absent holds the names of optional input resources that do not exist.
+	absent map[string]bool
+	// ttl is the shortest time the values of input resources resolved by an InputProvider are valid for.
+	ttl time.Duration
+}
+
+func newTemplateValues() templateValues {
+	return templateValues{
+		inputs:  map[string]interface{}{},
+		decoded: map[string]interface{}{},
+		encoded: map[string]map[string]bool{},
+		absent:  map[string]bool{},
+	}
+}
+
+// add makes an input resource available to expressions under name.
+func (v templateValues) add(name string, content map[string]interface{}) error {
+	decoded, fields, err := decodeEncodedFields(content)
+	if err != nil {
+		return err
+	}
+
+	v.inputs[name] = content
+	v.decoded[name] = decoded
+	v.addEncodedFields(name, fields)
+	return nil
+}
+
+// addList makes a list of input resources available to expressions as an array under name.
+func (v templateValues) addList(name string, contents []map[string]interface{}) error {
+	inputs := make([]interface{}, 0, len(contents))
+	decodedInputs := make([]interface{}, 0, len(contents))
+
+	for _, content := range contents {
+		decoded, fields, err := decodeEncodedFields(content)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, content)
+		decodedInputs = append(decodedInputs, decoded)
+		v.addEncodedFields(name, fields)
+	}
+
+	v.inputs[name] = inputs
+	v.decoded[name] = decodedInputs
+	return nil
+}
+
+// objects returns the input resources available under name with their base64 encoded fields decoded.
+func (v templateValues) objects(name string) ([]map[string]interface{}, bool) {
+	switch value := v.decoded[name].(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{value}, true
+	case []interface{}:
+		var objects []map[string]interface{}
+		for _, item := range value {
+			if obj, ok := item.(map[string]interface{}); ok {
+				objects = append(objects, obj)
+			}
+		}
+		return objects, true
+	default:
+		return nil, false
+	}
+}
+
+func (v templateValues) addEncodedFields(name string, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	if v.encoded[name] == nil {
+		v.encoded[name] = map[string]bool{}
+	}
+	for _, field := range fields {
+		v.encoded[name][field] = true
+	}
+}
+
+// readsEncodedField returns whether a JSONPath reads from a base64 encoded field of an input resource.
+func (v templateValues) readsEncodedField(path string) bool {
+	match := inputFieldPath.FindStringSubmatch(path)
+	if match == nil {
+		return false
+	}
+	return v.encoded[match[1]][match[2]]
+}
+
+// decodeEncodedFields returns a copy of content in which the values of base64 encoded fields are decoded,
+// along with the names of those fields.
+func decodeEncodedFields(content map[string]interface{}) (map[string]interface{}, []string, error) {
+	obj := unstructured.Unstructured{Object: content}
+	candidates := encodedFields[obj.GroupVersionKind()]
+	if len(candidates) == 0 {
+		return content, nil, nil
+	}
+
+	decodedContent := make(map[string]interface{}, len(content))
+	for k, v := range content {
+		decodedContent[k] = v
+	}
+
+	var fields []string
+	for _, field := range candidates {
+		fields = append(fields, field)
+
+		values, ok := content[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		decodedValues := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			strVal, ok := v.(string)
+			if !ok {
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(strVal)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed decoding base64 from %s %s, %s field %s: %w",
+					obj.GetKind(), obj.GetName(), field, k, err)
+			}
+			decodedValues[k] = string(decoded)
+		}
+		decodedContent[field] = decodedValues
+	}
+
+	return decodedContent, fields, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+)
+
+func evaluateURIs(uris map[string]tsv1alpha1.URITemplate, values templateValues) (map[string]string, error) {
+	evaluatedURIs := map[string]string{}
+	for key, uriTemplate := range uris {
+		uri, err := evaluateURI(uriTemplate, values)
+		if err != nil {
+			return nil, fmt.Errorf("uri %s: %w", key, err)
+		}
+		evaluatedURIs[key] = uri
+	}
+
+	return evaluatedURIs, nil
+}
+
+// evaluateURI templates every component of a URITemplate and composes them into a URI.
+// Components are evaluated individually so that escaping is applied to the resolved values
+// rather than to the template itself.
+func evaluateURI(uriTemplate tsv1alpha1.URITemplate, values templateValues) (string, error) {
+	component := func(name, expression string) (string, error) {
+		value, err := evaluateString(expression, values)
+		if err != nil {
+			return "", fmt.Errorf("templating %s: %w", name, err)
+		}
+		return value, nil
+	}
+
+	scheme, err := component("scheme", uriTemplate.Scheme)
+	if err != nil {
+		return "", err
+	}
+	if scheme == "" {
+		return "", fmt.Errorf("scheme must not be empty")
+	}
+
+	host, err := component("host", uriTemplate.Host)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		return "", fmt.Errorf("host must not be empty")
+	}
+
+	port, err := component("port", uriTemplate.Port)
+	if err != nil {
+		return "", err
+	}
+	// Only IPv6 addresses are bracketed, hosts like the seed list "a:27017,b:27017" are used as they are.
+	if bare := strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"); isIPv6(bare) {
+		host = "[" + bare + "]"
+	}
+	if port != "" {
+		if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+			return "", fmt.Errorf("port %q is not a valid port number", port)
+		}
+		host = host + ":" + port
+	}
+
+	uri := url.URL{
+		Scheme: scheme,
+		Host:   host,
+	}
+
+	username, err := component("username", uriTemplate.Username)
+	if err != nil {
+		return "", err
+	}
+	password, err := component("password", uriTemplate.Password)
+	if err != nil {
+		return "", err
+	}
+	// A password without a username yields userinfo like ":password", as used by Redis AUTH without ACL users.
+	switch {
+	case password != "":
+		uri.User = url.UserPassword(username, password)
+	case username != "":
+		uri.User = url.User(username)
+	}
+
+	path, err := component("path", uriTemplate.Path)
+	if err != nil {
+		return "", err
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	uri.Path = path
+
+	query := url.Values{}
+	for name, expression := range uriTemplate.Query {
+		value, err := component("query parameter "+name, expression)
+		if err != nil {
+			return "", err
+		}
+		query.Set(name, value)
+	}
+	uri.RawQuery = query.Encode()
+
+	return uri.String(), nil
+}
+
+func isIPv6(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
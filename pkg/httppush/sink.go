@@ -0,0 +1,209 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package httppush writes the data of Secrets to HTTP endpoints. The data is written with a PUT request and read back
+// with a GET request, both carrying a JSON object of the form {"data": {"key": "value"}}. Endpoints respond to GET
+// requests with status 404 if they hold no data.
+package httppush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Config configures which endpoints data may be pushed to.
+type Config struct {
+	// URL prefixes push targets must match, e.g. https://store.example.com/secrets/.
+	// A prefix matches URLs with the same scheme and host whose path starts with the path of the prefix.
+	AllowedURLPrefixes []string
+	// Timeout of a single request. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Lifetime of the Service Account tokens sent to endpoints, unless set by the SecretTemplate's Service Account.
+	// Tokens are always scoped to the push target, so their audiences are not configurable.
+	Tokens generator.TokenSettings
+}
+
+// Payload is the body of requests and responses exchanged with endpoints.
+type Payload struct {
+	Data map[string]string `json:"data"`
+}
+
+// Sink is a PushSink writing to HTTP endpoints. Sink is thread-safe.
+type Sink struct {
+	allowed      []*url.URL
+	httpClient   *http.Client
+	tokenManager generator.TokenManager
+	tokens       generator.TokenSettings
+}
+
+var _ generator.PushSink = &Sink{}
+
+// NewSink creates a new Sink. Requests carry a token of the SecretTemplate's Service Account, if one is set,
+// requested from tokenManager.
+func NewSink(config Config, tokenManager generator.TokenManager) (*Sink, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	var allowed []*url.URL
+	for _, prefix := range config.AllowedURLPrefixes {
+		parsed, err := parseURL(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("allowed url prefix %q: %w", prefix, err)
+		}
+		allowed = append(allowed, parsed)
+	}
+
+	return &Sink{
+		allowed: allowed,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+			// Redirects could lead to endpoints that are not allowed.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		tokenManager: tokenManager,
+		tokens:       config.Tokens,
+	}, nil
+}
+
+// Read returns the data held by the endpoint a push target refers to.
+func (s *Sink) Read(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget) (map[string]string, bool, error) {
+	var payload Payload
+	status, err := s.do(ctx, http.MethodGet, secretTemplate, target, nil, &payload)
+	if status == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return payload.Data, true, nil
+}
+
+// Write replaces the data held by the endpoint a push target refers to.
+func (s *Sink) Write(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget, data map[string]string) error {
+	_, err := s.do(ctx, http.MethodPut, secretTemplate, target, &Payload{Data: data}, nil)
+	return err
+}
+
+// do sends a request to the endpoint of a push target and decodes the response into out, unless out is nil.
+// It returns the status code of the response.
+func (s *Sink) do(ctx context.Context, method string, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget, in, out interface{}) (int, error) {
+	if target.HTTP == nil {
+		return 0, fmt.Errorf("push target %s does not refer to an http endpoint", target.Name)
+	}
+	endpoint, err := s.endpoint(target.HTTP.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if serviceAccount := secretTemplate.Spec.GetServiceAccount(); serviceAccount != nil {
+		tokenRequest := s.tokens.TokenRequest(*serviceAccount)
+		tokenRequest.Spec.Audiences = []string{audience(target.HTTP, endpoint)}
+		tokenRequest, err := s.tokenManager.GetServiceAccountToken(ctx, secretTemplate.Namespace, serviceAccount.Name, tokenRequest)
+		if err != nil {
+			return 0, fmt.Errorf("requesting service account token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+tokenRequest.Status.Token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%s %s responded with %d", method, endpoint.Redacted(), resp.StatusCode)
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decoding response of %s: %w", endpoint.Redacted(), err)
+	}
+	return resp.StatusCode, nil
+}
+
+// endpoint parses the URL of a push target and checks it matches an allowed prefix.
+func (s *Sink) endpoint(rawURL string) (*url.URL, error) {
+	endpoint, err := parseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("url %q: %w", rawURL, err)
+	}
+
+	for _, prefix := range s.allowed {
+		if endpoint.Scheme == prefix.Scheme && endpoint.Host == prefix.Host && hasPathPrefix(endpoint.Path, prefix.Path) {
+			return endpoint, nil
+		}
+	}
+	return nil, fmt.Errorf("url %q is not allowed", rawURL)
+}
+
+func parseURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("scheme must be http or https")
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("host must not be empty")
+	}
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment == "." || segment == ".." {
+			return nil, fmt.Errorf("path must not contain relative segments")
+		}
+	}
+	return parsed, nil
+}
+
+// hasPathPrefix returns whether path is prefix or lies below it.
+func hasPathPrefix(path, prefix string) bool {
+	if path == prefix || prefix == "" || prefix == "/" {
+		return true
+	}
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(path, prefix)
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// audience returns the audience of the Service Account tokens sent to an endpoint, either the one configured for the
+// target or the URL of the endpoint without credentials and query.
+func audience(target *tsv1alpha1.HTTPPushTarget, endpoint *url.URL) string {
+	if target.Audience != "" {
+		return target.Audience
+	}
+	scoped := *endpoint
+	scoped.User = nil
+	scoped.RawQuery = ""
+	scoped.Fragment = ""
+	return scoped.String()
+}
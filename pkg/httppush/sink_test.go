@@ -0,0 +1,155 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package httppush_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/drae/templated-secret-controller/pkg/httppush"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Sink(t *testing.T) {
+	store := &fakeStore{data: map[string]map[string]string{}}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	sink, err := httppush.NewSink(httppush.Config{
+		AllowedURLPrefixes: []string{server.URL + "/secrets"},
+		Tokens:             generator.TokenSettings{Expiration: 2 * time.Hour, Audiences: []string{"https://kubernetes.default.svc"}},
+	}, fakeTokenManager{})
+	require.NoError(t, err)
+
+	data := map[string]string{"username": "admin", "password": "p@ss"}
+
+	t.Run("writes and reads data", func(t *testing.T) {
+		target := pushTarget(server.URL + "/secrets/db")
+
+		_, found, err := sink.Read(context.Background(), secretTemplate("writer"), target)
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		require.NoError(t, sink.Write(context.Background(), secretTemplate("writer"), target, data))
+		assert.Equal(t, "Bearer jwt-test-writer-"+server.URL+"/secrets/db-7200", store.lastAuthorization())
+
+		current, found, err := sink.Read(context.Background(), secretTemplate("writer"), target)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, data, current)
+	})
+
+	t.Run("passes a token for the configured audience", func(t *testing.T) {
+		target := pushTarget(server.URL + "/secrets/db?version=2")
+		target.HTTP.Audience = "store.example.com"
+		require.NoError(t, sink.Write(context.Background(), secretTemplate("writer"), target, data))
+		assert.Equal(t, "Bearer jwt-test-writer-store.example.com-7200", store.lastAuthorization())
+	})
+
+	t.Run("passes a token scoped to the target with the lifetime of the service account", func(t *testing.T) {
+		expiration := int64(600)
+		secretTemplate := secretTemplate("")
+		secretTemplate.Spec.ServiceAccount = &tsv1alpha1.SecretTemplateServiceAccount{Name: "writer", Audiences: []string{"https://oidc.example.com"}, ExpirationSeconds: &expiration}
+
+		require.NoError(t, sink.Write(context.Background(), secretTemplate, pushTarget(server.URL+"/secrets/db"), data))
+		assert.Equal(t, "Bearer jwt-test-writer-"+server.URL+"/secrets/db-600", store.lastAuthorization())
+	})
+
+	t.Run("does not pass a token without a service account", func(t *testing.T) {
+		require.NoError(t, sink.Write(context.Background(), secretTemplate(""), pushTarget(server.URL+"/secrets/db"), data))
+		assert.Empty(t, store.lastAuthorization())
+	})
+
+	t.Run("endpoint errors", func(t *testing.T) {
+		err := sink.Write(context.Background(), secretTemplate("writer"), pushTarget(server.URL+"/secrets/read-only"), data)
+		assert.EqualError(t, err, fmt.Sprintf("PUT %s/secrets/read-only responded with 403", server.URL))
+	})
+
+	t.Run("urls that are not allowed", func(t *testing.T) {
+		for _, url := range []string{
+			server.URL + "/other",
+			server.URL + "/secretsother",
+			server.URL + "/secrets/../other",
+			"http://other.example.com/secrets/db",
+			"file:///secrets/db",
+		} {
+			err := sink.Write(context.Background(), secretTemplate("writer"), pushTarget(url), data)
+			assert.Error(t, err, url)
+		}
+	})
+}
+
+func secretTemplate(serviceAccount string) *tsv1alpha1.SecretTemplate {
+	return &tsv1alpha1.SecretTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "secretTemplate", Namespace: "test"},
+		Spec:       tsv1alpha1.SecretTemplateSpec{ServiceAccountName: serviceAccount},
+	}
+}
+
+func pushTarget(url string) tsv1alpha1.PushTarget {
+	return tsv1alpha1.PushTarget{Name: "store", HTTP: &tsv1alpha1.HTTPPushTarget{URL: url}}
+}
+
+type fakeTokenManager struct{}
+
+func (fakeTokenManager) GetServiceAccountToken(_ context.Context, namespace, name string, tr *authv1.TokenRequest) (*authv1.TokenRequest, error) {
+	tr.Status.Token = fmt.Sprintf("jwt-%s-%s-%s-%d", namespace, name, strings.Join(tr.Spec.Audiences, ","), *tr.Spec.ExpirationSeconds)
+	return tr, nil
+}
+
+// fakeStore holds data by path. Writes to /secrets/read-only are forbidden.
+type fakeStore struct {
+	mu            sync.Mutex
+	data          map[string]map[string]string
+	authorization string
+}
+
+func (f *fakeStore) lastAuthorization() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.authorization
+}
+
+func (f *fakeStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.authorization = r.Header.Get("Authorization")
+
+	switch r.Method {
+	case http.MethodGet:
+		data, found := f.data[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(httppush.Payload{Data: data})
+	case http.MethodPut:
+		if r.URL.Path == "/secrets/read-only" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var payload httppush.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.data[r.URL.Path] = payload.Data
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
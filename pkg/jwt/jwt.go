@@ -0,0 +1,240 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jwt signs and verifies JSON Web Tokens in compact serialization.
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Header is the JOSE header of a token.
+type Header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Sign returns a token carrying claims signed using algorithm. For HS256 key is the shared secret,
+// for all other algorithms a PEM encoded private key in PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) form.
+func Sign(algorithm string, key []byte, keyID string, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(Header{Algorithm: algorithm, Type: "JWT", KeyID: keyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encode(header) + "." + encode(payload)
+	signature, err := sign(algorithm, key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encode(signature), nil
+}
+
+// Verify checks that token is signed using algorithm by key and returns its header and claims.
+// Numeric claims are returned as json.Number. The validity period of the token is not checked.
+func Verify(token string, algorithm string, key []byte) (Header, map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Header{}, nil, fmt.Errorf("malformed token")
+	}
+
+	var header Header
+	if err := decodeJSON(parts[0], &header); err != nil {
+		return Header{}, nil, fmt.Errorf("decoding header: %w", err)
+	}
+	if header.Algorithm != algorithm {
+		return Header{}, nil, fmt.Errorf("token is signed using %s, expected %s", header.Algorithm, algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Header{}, nil, fmt.Errorf("decoding signature: %w", err)
+	}
+	if err := verify(algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return Header{}, nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJSON(parts[1], &claims); err != nil {
+		return Header{}, nil, fmt.Errorf("decoding claims: %w", err)
+	}
+	return header, claims, nil
+}
+
+func sign(algorithm string, key, signingInput []byte) ([]byte, error) {
+	if err := supported(algorithm); err != nil {
+		return nil, err
+	}
+	if algorithm == HS256 {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("signing key must not be empty")
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(signingInput)
+		return mac.Sum(nil), nil
+	}
+
+	privateKey, err := parsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(signingInput)
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != RS256 {
+			break
+		}
+		return rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		if algorithm != ES256 {
+			break
+		}
+		if privateKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ES256 requires a P-256 key")
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS signatures are the fixed size concatenation of r and s rather than ASN.1.
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	case ed25519.PrivateKey:
+		if algorithm != EdDSA {
+			break
+		}
+		return ed25519.Sign(privateKey, signingInput), nil
+	}
+	return nil, unsupportedKey(algorithm, privateKey)
+}
+
+func verify(algorithm string, key, signingInput, signature []byte) error {
+	if err := supported(algorithm); err != nil {
+		return err
+	}
+	if algorithm == HS256 {
+		expected, err := sign(algorithm, key, signingInput)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(expected, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+
+	privateKey, err := parsePrivateKey(key)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(signingInput)
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != RS256 {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case *ecdsa.PrivateKey:
+		if algorithm != ES256 {
+			break
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(&privateKey.PublicKey, digest[:], r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case ed25519.PrivateKey:
+		if algorithm != EdDSA {
+			break
+		}
+		if !ed25519.Verify(privateKey.Public().(ed25519.PublicKey), signingInput, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return unsupportedKey(algorithm, privateKey)
+}
+
+func parsePrivateKey(key []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, fmt.Errorf("signing key is not PEM encoded")
+	}
+
+	if privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	if privateKey, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	return nil, fmt.Errorf("signing key is not a PKCS #8, PKCS #1 or SEC 1 private key")
+}
+
+func supported(algorithm string) error {
+	switch algorithm {
+	case HS256, RS256, ES256, EdDSA:
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q, must be one of %s, %s, %s or %s", algorithm, HS256, RS256, ES256, EdDSA)
+	}
+}
+
+func unsupportedKey(algorithm string, key crypto.PrivateKey) error {
+	return fmt.Errorf("signing key of type %T can not be used with %s", key, algorithm)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJSON(part string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
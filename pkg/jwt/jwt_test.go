@@ -0,0 +1,107 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/drae/templated-secret-controller/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		algorithm string
+		key       []byte
+	}{
+		{"HS256", jwt.HS256, []byte("shared-secret")},
+		{"RS256 PKCS #1", jwt.RS256, pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))},
+		{"RS256 PKCS #8", jwt.RS256, pkcs8(t, rsaKey)},
+		{"ES256 SEC 1", jwt.ES256, pemBlock("EC PRIVATE KEY", ecDER)},
+		{"ES256 PKCS #8", jwt.ES256, pkcs8(t, ecKey)},
+		{"EdDSA", jwt.EdDSA, pkcs8(t, edKey)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwt.Sign(tc.algorithm, tc.key, "key-1", map[string]interface{}{"sub": "app", "iat": 1700000000})
+			require.NoError(t, err)
+
+			header, claims, err := jwt.Verify(token, tc.algorithm, tc.key)
+			require.NoError(t, err)
+			assert.Equal(t, jwt.Header{Algorithm: tc.algorithm, Type: "JWT", KeyID: "key-1"}, header)
+			assert.Equal(t, map[string]interface{}{"sub": "app", "iat": json.Number("1700000000")}, claims)
+
+			_, _, err = jwt.Verify(token[:len(token)-4]+"AAAA", tc.algorithm, tc.key)
+			assert.EqualError(t, err, "invalid signature")
+		})
+	}
+
+	t.Run("mismatched algorithm", func(t *testing.T) {
+		token, err := jwt.Sign(jwt.HS256, []byte("shared-secret"), "", nil)
+		require.NoError(t, err)
+		_, _, err = jwt.Verify(token, jwt.RS256, pkcs8(t, rsaKey))
+		assert.EqualError(t, err, "token is signed using HS256, expected RS256")
+
+		_, err = jwt.Sign(jwt.ES256, pkcs8(t, rsaKey), "", nil)
+		assert.EqualError(t, err, "signing key of type *rsa.PrivateKey can not be used with ES256")
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := jwt.Sign(jwt.RS256, []byte("not-pem"), "", nil)
+		assert.EqualError(t, err, "signing key is not PEM encoded")
+
+		_, err = jwt.Sign(jwt.HS256, nil, "", nil)
+		assert.EqualError(t, err, "signing key must not be empty")
+
+		_, err = jwt.Sign("none", []byte("shared-secret"), "", nil)
+		assert.EqualError(t, err, `unsupported algorithm "none", must be one of HS256, RS256, ES256 or EdDSA`)
+	})
+}
+
+// Example from RFC 7515, appendix A.1
+func Test_Verify_RFC7515(t *testing.T) {
+	key, err := base64.RawURLEncoding.DecodeString("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	require.NoError(t, err)
+
+	token := "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9" +
+		".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ" +
+		".dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	_, claims, err := jwt.Verify(token, jwt.HS256, key)
+	require.NoError(t, err)
+	assert.Equal(t, "joe", claims["iss"])
+	assert.Equal(t, json.Number("1300819380"), claims["exp"])
+}
+
+func pkcs8(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pemBlock("PRIVATE KEY", der)
+}
+
+func pemBlock(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
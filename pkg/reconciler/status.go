@@ -19,6 +19,8 @@ import (
 // It's indicative to not requeue a reconcile request.
 type TerminalReconcileErr struct {
 	Err error
+	// Reason is reported as the reason of the ReconcileFailed condition.
+	Reason string
 }
 
 func (e TerminalReconcileErr) Error() string { return e.Err.Error() }
@@ -57,9 +59,14 @@ func (s *Status) SetReconcileCompleted(err error) {
 	s.removeAllConditions()
 
 	if err != nil {
+		var reason string
+		if terminalErr, ok := err.(TerminalReconcileErr); ok {
+			reason = terminalErr.Reason
+		}
 		s.S.Conditions = append(s.S.Conditions, tsv1alpha1.Condition{
 			Type:    tsv1alpha1.ReconcileFailed,
 			Status:  corev1.ConditionTrue,
+			Reason:  reason,
 			Message: err.Error(),
 		})
 		s.S.FriendlyDescription = s.friendlyErrMsg(fmt.Sprintf("Reconcile failed: %s", err))
@@ -75,6 +82,23 @@ func (s *Status) SetReconcileCompleted(err error) {
 	s.UpdateFunc(s.S)
 }
 
+// SetSuspended reports that reconciliation is suspended for the given reason.
+func (s *Status) SetSuspended(meta metav1.ObjectMeta, reason, message string) {
+	s.markObservedLatest(meta)
+	s.removeAllConditions()
+
+	s.S.Conditions = append(s.S.Conditions, tsv1alpha1.Condition{
+		Type:    tsv1alpha1.Suspended,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+
+	s.S.FriendlyDescription = "Suspended"
+
+	s.UpdateFunc(s.S)
+}
+
 func (s *Status) friendlyErrMsg(errMsg string) string {
 	errMsgPieces := strings.Split(errMsg, "\n")
 	if len(errMsgPieces[0]) > 80 {
@@ -138,6 +138,36 @@ func TestSetReconcileCompleted_Failure(t *testing.T) {
 	assert.Equal(t, "Reconcile failed: test error", updatedStatus.FriendlyDescription)
 }
 
+func TestSetSuspended(t *testing.T) {
+	var updatedStatus tsv1alpha1.GenericStatus
+
+	status := reconciler.Status{
+		S: tsv1alpha1.GenericStatus{
+			Conditions: []tsv1alpha1.Condition{
+				{
+					Type:   tsv1alpha1.ReconcileSucceeded,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+		UpdateFunc: func(s tsv1alpha1.GenericStatus) {
+			updatedStatus = s
+		},
+	}
+
+	status.SetSuspended(metav1.ObjectMeta{Generation: 7}, "SuspendedBySpec", "suspended")
+
+	assert.Equal(t, int64(7), updatedStatus.ObservedGeneration)
+	assert.Equal(t, []tsv1alpha1.Condition{{
+		Type:    tsv1alpha1.Suspended,
+		Status:  corev1.ConditionTrue,
+		Reason:  "SuspendedBySpec",
+		Message: "suspended",
+	}}, updatedStatus.Conditions)
+	assert.Equal(t, "Suspended", updatedStatus.FriendlyDescription)
+	assert.False(t, status.IsReconcileSucceeded())
+}
+
 func TestFriendlyErrMsg(t *testing.T) {
 	var updatedStatus tsv1alpha1.GenericStatus
 
@@ -198,12 +228,13 @@ func TestWithReconcileCompleted_TerminalError(t *testing.T) {
 	}
 
 	// With terminal error
-	terminalErr := reconciler.TerminalReconcileErr{Err: errors.New("terminal error")}
+	terminalErr := reconciler.TerminalReconcileErr{Err: errors.New("terminal error"), Reason: "TerminalReason"}
 	result, err := status.WithReconcileCompleted(reconcile.Result{}, terminalErr)
 
 	// Verify the status was updated correctly
 	assert.Equal(t, 1, len(updatedStatus.Conditions))
 	assert.Equal(t, tsv1alpha1.ReconcileFailed, updatedStatus.Conditions[0].Type)
+	assert.Equal(t, "TerminalReason", updatedStatus.Conditions[0].Reason)
 	assert.Equal(t, "terminal error", updatedStatus.Conditions[0].Message)
 
 	// Terminal errors should return empty Result and nil error
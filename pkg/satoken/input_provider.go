@@ -0,0 +1,86 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package satoken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+)
+
+const defaultInputExpirationSeconds = int64(3600)
+
+var _ generator.InputProvider = &InputProvider{}
+
+// InputProvider resolves input resources to bound tokens of ServiceAccounts.
+type InputProvider struct {
+	issuer generator.TokenIssuer
+	// Audiences of tokens of input resources that do not set any
+	audiences []string
+
+	// mocked for testing
+	clock clock.Clock
+}
+
+// NewInputProvider returns a new InputProvider requesting tokens from issuer. Tokens default to audiences rather than
+// those of the API server, so that they can only be used to authenticate to the API server if asked for.
+func NewInputProvider(issuer generator.TokenIssuer, audiences []string) *InputProvider {
+	return &InputProvider{
+		issuer:    issuer,
+		audiences: audiences,
+		clock:     clock.RealClock{},
+	}
+}
+
+// Resolve requests a token for the ServiceAccount of an input resource. The values expire once the token manager
+// refreshes the token, so that the SecretTemplate picks up the new token before the old one expires.
+func (p *InputProvider) Resolve(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, input tsv1alpha1.InputResource) (generator.ProvidedInput, error) {
+	source := input.ServiceAccountToken
+	ownServiceAccount := secretTemplate.Spec.GetServiceAccountName()
+	if ownServiceAccount == "" {
+		return generator.ProvidedInput{}, fmt.Errorf("unable to request service account tokens without a specified serviceaccount")
+	}
+
+	serviceAccount := source.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = ownServiceAccount
+	}
+
+	audiences := p.audiences
+	if len(source.Audiences) > 0 {
+		audiences = source.Audiences
+	}
+	expiration := defaultInputExpirationSeconds
+	if source.ExpirationSeconds != nil {
+		expiration = *source.ExpirationSeconds
+	}
+	tokenRequest, err := p.issuer.IssueToken(ctx, secretTemplate, serviceAccount, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         audiences,
+			ExpirationSeconds: &expiration,
+		},
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return generator.ProvidedInput{}, fmt.Errorf("requesting token for serviceaccount %s: %w", serviceAccount, generator.ErrInputNotFound)
+		}
+		return generator.ProvidedInput{}, fmt.Errorf("requesting token for serviceaccount %s: %w", serviceAccount, err)
+	}
+
+	expirationTimestamp := tokenRequest.Status.ExpirationTimestamp.Time
+	return generator.ProvidedInput{
+		Values: map[string]interface{}{
+			"token":               tokenRequest.Status.Token,
+			"expirationTimestamp": expirationTimestamp.UTC().Format(time.RFC3339),
+		},
+		// The token manager refreshes tokens once half of their remaining lifetime has passed
+		TTL: max(expirationTimestamp.Sub(p.clock.Now())/2, time.Second),
+	}, nil
+}
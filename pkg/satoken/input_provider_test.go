@@ -0,0 +1,160 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package satoken
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestInputProvider(t *testing.T) {
+	clock := testingclock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	type request struct {
+		namespace, name string
+		spec            authenticationv1.TokenRequestSpec
+	}
+	var requests []request
+	tokenManager := tokenManagerFunc(func(_ context.Context, namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+		if name == "missing" {
+			return nil, errors.NewNotFound(schema.GroupResource{Resource: "serviceaccounts"}, name)
+		}
+		requests = append(requests, request{namespace, name, tr.Spec})
+		tr = tr.DeepCopy()
+		tr.Status.Token = "token-" + name
+		tr.Status.ExpirationTimestamp = metav1.NewTime(clock.Now().Add(time.Duration(*tr.Spec.ExpirationSeconds) * time.Second))
+		return tr, nil
+	})
+
+	var reviews []authorizationv1.SubjectAccessReviewSpec
+	provider := &InputProvider{
+		issuer: &Issuer{
+			tokenManager:  tokenManager,
+			maxExpiration: 2 * time.Hour,
+			reviewAccess: func(_ context.Context, sar *authorizationv1.SubjectAccessReview) (*authorizationv1.SubjectAccessReview, error) {
+				reviews = append(reviews, sar.Spec)
+				sar.Status.Allowed = sar.Spec.ResourceAttributes.Name != "denied" && sar.Spec.User != "system:serviceaccount:ns:exporter"
+				return sar, nil
+			},
+		},
+		audiences: []string{"templatedsecret.starstreak.dev/service-account-token"},
+		clock:     clock,
+	}
+
+	secretTemplate := &tsv1alpha1.SecretTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: "ns"},
+		Spec:       tsv1alpha1.SecretTemplateSpec{ServiceAccountName: "template-sa"},
+	}
+	input := func(source tsv1alpha1.ServiceAccountTokenInputSource) tsv1alpha1.InputResource {
+		return tsv1alpha1.InputResource{Name: "token", ServiceAccountToken: &source}
+	}
+
+	t.Run("own service account", func(t *testing.T) {
+		requests, reviews = nil, nil
+		expiration := int64(7200)
+
+		provided, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{
+			Audiences:         []string{"api"},
+			ExpirationSeconds: &expiration,
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]interface{}{
+			"token":               "token-template-sa",
+			"expirationTimestamp": "2024-01-01T02:00:00Z",
+		}, provided.Values)
+		assert.Equal(t, time.Hour, provided.TTL)
+		assert.Equal(t, []request{{"ns", "template-sa", authenticationv1.TokenRequestSpec{Audiences: []string{"api"}, ExpirationSeconds: &expiration}}}, requests)
+
+		// Tokens of the SecretTemplate's own ServiceAccount are exported as well, so they require access too.
+		require.Len(t, reviews, 1)
+		assert.Equal(t, "system:serviceaccount:ns:template-sa", reviews[0].User)
+		assert.Equal(t, "template-sa", reviews[0].ResourceAttributes.Name)
+	})
+
+	t.Run("own service account without access", func(t *testing.T) {
+		requests = nil
+		secretTemplate := secretTemplate.DeepCopy()
+		secretTemplate.Spec.ServiceAccountName = "exporter"
+
+		_, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{}))
+		assert.EqualError(t, err, "requesting token for serviceaccount exporter: serviceaccount exporter is not allowed to create tokens for serviceaccount exporter")
+		assert.Empty(t, requests)
+	})
+
+	t.Run("defaults to the audiences of the controller", func(t *testing.T) {
+		requests = nil
+
+		_, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{}))
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, []string{"templatedsecret.starstreak.dev/service-account-token"}, requests[0].spec.Audiences)
+	})
+
+	t.Run("lifetime exceeding the maximum", func(t *testing.T) {
+		requests = nil
+		expiration := int64(3 * 3600)
+
+		_, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{ExpirationSeconds: &expiration}))
+		assert.EqualError(t, err, "requesting token for serviceaccount template-sa: expirationSeconds 10800 exceeds the maximum of 7200")
+		assert.Empty(t, requests)
+	})
+
+	t.Run("other service account", func(t *testing.T) {
+		requests, reviews = nil, nil
+
+		provided, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{ServiceAccountName: "allowed"}))
+		require.NoError(t, err)
+		assert.Equal(t, "token-allowed", provided.Values["token"])
+		assert.Equal(t, 30*time.Minute, provided.TTL)
+
+		require.Len(t, reviews, 1)
+		assert.Equal(t, "system:serviceaccount:ns:template-sa", reviews[0].User)
+		assert.Equal(t, &authorizationv1.ResourceAttributes{
+			Namespace:   "ns",
+			Verb:        "create",
+			Resource:    "serviceaccounts",
+			Subresource: "token",
+			Name:        "allowed",
+		}, reviews[0].ResourceAttributes)
+
+		_, err = provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{ServiceAccountName: "denied"}))
+		assert.EqualError(t, err, "requesting token for serviceaccount denied: serviceaccount template-sa is not allowed to create tokens for serviceaccount denied")
+		assert.Len(t, requests, 1)
+	})
+
+	t.Run("missing service account", func(t *testing.T) {
+		secretTemplate := secretTemplate.DeepCopy()
+		secretTemplate.Spec.ServiceAccountName = "missing"
+
+		_, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{}))
+		assert.ErrorIs(t, err, generator.ErrInputNotFound)
+	})
+
+	t.Run("without service account", func(t *testing.T) {
+		secretTemplate := secretTemplate.DeepCopy()
+		secretTemplate.Spec.ServiceAccountName = ""
+
+		_, err := provider.Resolve(context.Background(), secretTemplate, input(tsv1alpha1.ServiceAccountTokenInputSource{ServiceAccountName: "allowed"}))
+		assert.EqualError(t, err, "unable to request service account tokens without a specified serviceaccount")
+	})
+}
+
+type tokenManagerFunc func(ctx context.Context, namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error)
+
+func (f tokenManagerFunc) GetServiceAccountToken(ctx context.Context, namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+	return f(ctx, namespace, name, tr)
+}
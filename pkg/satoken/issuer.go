@@ -0,0 +1,81 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package satoken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+var _ generator.TokenIssuer = &Issuer{}
+
+// Issuer issues tokens of ServiceAccounts that SecretTemplates write into Secrets. Tokens are only issued if the
+// ServiceAccount of the SecretTemplate is allowed to create them, including tokens for itself, as reading input
+// resources as a ServiceAccount does not imply being allowed to export its credentials.
+type Issuer struct {
+	tokenManager  generator.TokenManager
+	maxExpiration time.Duration
+
+	// mocked for testing
+	reviewAccess func(ctx context.Context, sar *authorizationv1.SubjectAccessReview) (*authorizationv1.SubjectAccessReview, error)
+}
+
+// NewIssuer returns a new Issuer requesting tokens from tokenManager with a lifetime of at most maxExpiration.
+func NewIssuer(c clientset.Interface, tokenManager generator.TokenManager, maxExpiration time.Duration) *Issuer {
+	return &Issuer{
+		tokenManager:  tokenManager,
+		maxExpiration: maxExpiration,
+		reviewAccess: func(ctx context.Context, sar *authorizationv1.SubjectAccessReview) (*authorizationv1.SubjectAccessReview, error) {
+			return c.AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+		},
+	}
+}
+
+// IssueToken requests a token for serviceAccount in the namespace of a SecretTemplate once the ServiceAccount of the
+// SecretTemplate is allowed to create it. The request must set a lifetime of at most the maximum of the Issuer.
+func (i *Issuer) IssueToken(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, serviceAccount string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+	if tr.Spec.ExpirationSeconds == nil {
+		return nil, fmt.Errorf("token requests must set expirationSeconds")
+	}
+	if maxSeconds := int64(i.maxExpiration.Seconds()); *tr.Spec.ExpirationSeconds > maxSeconds {
+		return nil, fmt.Errorf("expirationSeconds %d exceeds the maximum of %d", *tr.Spec.ExpirationSeconds, maxSeconds)
+	}
+	if err := i.authorize(ctx, secretTemplate, serviceAccount); err != nil {
+		return nil, err
+	}
+	return i.tokenManager.GetServiceAccountToken(ctx, secretTemplate.Namespace, serviceAccount, tr)
+}
+
+// authorize ensures the ServiceAccount of a SecretTemplate is allowed to create tokens for serviceAccount.
+func (i *Issuer) authorize(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, serviceAccount string) error {
+	namespace := secretTemplate.Namespace
+	review, err := i.reviewAccess(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   fmt.Sprintf("system:serviceaccount:%s:%s", namespace, secretTemplate.Spec.GetServiceAccountName()),
+			Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        "create",
+				Resource:    "serviceaccounts",
+				Subresource: "token",
+				Name:        serviceAccount,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("reviewing access to serviceaccount %s: %w", serviceAccount, err)
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("serviceaccount %s is not allowed to create tokens for serviceaccount %s", secretTemplate.Spec.GetServiceAccountName(), serviceAccount)
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,28 +26,38 @@ import (
 )
 
 const (
-	maxTTL    = 2 * time.Hour
-	gcPeriod  = time.Hour * 24
-	maxJitter = 10 * time.Second
+	maxTTL         = 2 * time.Hour
+	gcPeriod       = time.Hour * 24
+	maxJitter      = 10 * time.Second
+	refreshPeriod  = time.Minute
+	refreshTimeout = 30 * time.Second
 )
 
-var _ generator.TokenManager = &Manager{}
+var (
+	_ generator.TokenManager          = &Manager{}
+	_ generator.RejectedTokenReviewer = &Manager{}
+)
 
-// NewManager returns a new token manager.
+// NewManager returns a new token manager, cleaning up expired tokens and refreshing tokens in use in the background.
 func NewManager(c clientset.Interface, log logr.Logger) *Manager {
-	m := &Manager{
+	m := newManager(c, log)
+	go wait.Forever(m.cleanup, gcPeriod)
+	go wait.Forever(m.refresh, refreshPeriod)
+	return m
+}
+
+func newManager(c clientset.Interface, log logr.Logger) *Manager {
+	return &Manager{
 		getToken: func(ctx context.Context, name, namespace string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
 			return c.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name, tr, metav1.CreateOptions{})
 		},
 		reviewToken: func(ctx context.Context, tr *authenticationv1.TokenReview) (*authenticationv1.TokenReview, error) {
 			return c.AuthenticationV1().TokenReviews().Create(ctx, tr, metav1.CreateOptions{})
 		},
-		cache: make(map[string]*authenticationv1.TokenRequest),
+		cache: make(map[string]*cachedToken),
 		clock: clock.RealClock{},
 		log:   log,
 	}
-	go wait.Forever(m.cleanup, gcPeriod)
-	return m
 }
 
 // Manager manages service account tokens for pods.
@@ -54,7 +65,7 @@ type Manager struct {
 
 	// cacheMutex guards the cache
 	cacheMutex sync.RWMutex
-	cache      map[string]*authenticationv1.TokenRequest
+	cache      map[string]*cachedToken
 
 	// mocked for testing
 	getToken    func(ctx context.Context, name, namespace string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error)
@@ -64,78 +75,166 @@ type Manager struct {
 	log logr.Logger
 }
 
+// cachedToken is a token along with the request it was issued for, which is repeated to refresh it.
+type cachedToken struct {
+	name      string
+	namespace string
+	request   *authenticationv1.TokenRequest
+	token     *authenticationv1.TokenRequest
+	// lastUsed is when the token was last returned, tokens not used since they were issued are not refreshed in the background.
+	lastUsed time.Time
+}
+
 // GetServiceAccountToken gets a service account token from cache or
 // from the TokenRequest API. This process is as follows:
-// * Check the cache for the current token request.
+// * Check the cache for the current token request, unless a refresh is requested by the context.
 // * If the token exists and does not require a refresh, return the current token.
 // * Attempt to refresh the token.
 // * If the token is refreshed successfully, save it in the cache and return the token.
 // * If refresh fails and the old token is still valid, log an error and return the old token.
 // * If refresh fails and the old token is no longer valid, return an error
+//
+// Whether a token requires a refresh is determined by its issue and expiration time alone. Tokens in use are refreshed
+// in the background before they are due, tokens rejected by the API server are reviewed by RejectedToken.
 func (m *Manager) GetServiceAccountToken(ctx context.Context, namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
-	key := fmt.Sprintf("%q/%q", name, namespace)
+	key := cacheKey(name, namespace, tr)
 
 	ctr, ok := m.get(key)
 
-	if ok && !m.requiresRefresh(ctx, ctr) {
+	// Cached tokens are bypassed when a refresh is requested, e.g. to regenerate a Secret holding the token.
+	if ok && !generator.RefreshRequested(ctx) && !m.requiresRefresh(ctr) {
 		return ctr, nil
 	}
 
+	request := tr.DeepCopy()
 	tr, err := m.getToken(ctx, name, namespace, tr)
 	if err != nil {
 		switch {
 		case !ok:
-			return nil, fmt.Errorf("Fetch token: %v", err)
+			return nil, fmt.Errorf("Fetch token: %w", err)
 		case m.expired(ctr):
-			return nil, fmt.Errorf("Token %s expired and refresh failed: %v", key, err)
+			return nil, fmt.Errorf("Token %s expired and refresh failed: %w", key, err)
 		default:
 			m.log.Error(err, "Update token", "cacheKey", key)
 			return ctr, nil
 		}
 	}
 
-	m.set(key, tr)
+	m.set(key, &cachedToken{name: name, namespace: namespace, request: request, token: tr, lastUsed: m.clock.Now()})
 	return tr, nil
 }
 
+// RejectedToken reviews a token the API server responded to with 401 Unauthorized, e.g. because its ServiceAccount was
+// recreated. Tokens that are no longer authenticated are evicted from the cache, so that the next lookup requests a new one.
+func (m *Manager) RejectedToken(ctx context.Context, namespace, name string, tr *authenticationv1.TokenRequest) {
+	key := cacheKey(name, namespace, tr)
+
+	m.cacheMutex.RLock()
+	entry, ok := m.cache[key]
+	m.cacheMutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	review, err := m.reviewToken(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     entry.token.Status.Token,
+			Audiences: entry.request.Spec.Audiences,
+		},
+	})
+	if err == nil && review.Status.Authenticated {
+		return
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	// The token may have been refreshed in the meantime.
+	if m.cache[key] == entry {
+		m.log.Info("Evicting token rejected by the API server", "cacheKey", key)
+		delete(m.cache, key)
+	}
+}
+
+// cacheKey identifies tokens by the ServiceAccount they are issued for and the audiences and lifetime they were requested with.
+func cacheKey(name, namespace string, tr *authenticationv1.TokenRequest) string {
+	var expirationSeconds int64
+	if tr.Spec.ExpirationSeconds != nil {
+		expirationSeconds = *tr.Spec.ExpirationSeconds
+	}
+	return fmt.Sprintf("%q/%q/%q/%d", name, namespace, strings.Join(tr.Spec.Audiences, ","), expirationSeconds)
+}
+
 func (m *Manager) cleanup() {
 	m.cacheMutex.Lock()
 	defer m.cacheMutex.Unlock()
-	for k, tr := range m.cache {
-		if m.expired(tr) {
+	for k, ct := range m.cache {
+		if m.expired(ct.token) {
 			delete(m.cache, k)
 		}
 	}
 }
 
-func (m *Manager) get(key string) (*authenticationv1.TokenRequest, bool) {
+// refresh renews tokens due for a refresh ahead of their next lookup. Only tokens used since they were issued are
+// renewed, others are left to expire.
+func (m *Manager) refresh() {
 	m.cacheMutex.RLock()
-	defer m.cacheMutex.RUnlock()
-	ctr, ok := m.cache[key]
-	return ctr, ok
+	due := map[string]*cachedToken{}
+	for k, ct := range m.cache {
+		if ct.lastUsed.After(issuedAt(ct.token)) && m.requiresRefresh(ct.token) {
+			due[k] = ct
+		}
+	}
+	m.cacheMutex.RUnlock()
+
+	for k, ct := range due {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		tr, err := m.getToken(ctx, ct.name, ct.namespace, ct.request.DeepCopy())
+		cancel()
+		if err != nil {
+			m.log.Error(err, "Refresh token", "cacheKey", k)
+			continue
+		}
+
+		m.cacheMutex.Lock()
+		// Tokens evicted in the meantime are not cached again.
+		if m.cache[k] == ct {
+			m.cache[k] = &cachedToken{name: ct.name, namespace: ct.namespace, request: ct.request, token: tr}
+		}
+		m.cacheMutex.Unlock()
+	}
+}
+
+func (m *Manager) get(key string) (*authenticationv1.TokenRequest, bool) {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	ct, ok := m.cache[key]
+	if !ok {
+		return nil, false
+	}
+	ct.lastUsed = m.clock.Now()
+	return ct.token, true
 }
 
-func (m *Manager) set(key string, tr *authenticationv1.TokenRequest) {
+func (m *Manager) set(key string, ct *cachedToken) {
 	m.cacheMutex.Lock()
 	defer m.cacheMutex.Unlock()
-	m.cache[key] = tr
+	m.cache[key] = ct
 }
 
 func (m *Manager) expired(t *authenticationv1.TokenRequest) bool {
 	return m.clock.Now().After(t.Status.ExpirationTimestamp.Time)
 }
 
-// requiresRefresh returns true if the token is older half of it's maxTTL
-func (m *Manager) requiresRefresh(ctx context.Context, tr *authenticationv1.TokenRequest) bool {
-	review, err := m.reviewToken(ctx, &authenticationv1.TokenReview{
-		Spec: authenticationv1.TokenReviewSpec{
-			Token: tr.Status.Token,
-		},
-	})
-	if err != nil || !review.Status.Authenticated {
-		return true
+// issuedAt returns when a token was issued, derived from its expiration time and lifetime.
+func issuedAt(tr *authenticationv1.TokenRequest) time.Time {
+	if tr.Spec.ExpirationSeconds == nil {
+		return time.Time{}
 	}
+	return tr.Status.ExpirationTimestamp.Add(-1 * time.Duration(*tr.Spec.ExpirationSeconds) * time.Second)
+}
 
+// requiresRefresh returns true if the token is older half of it's maxTTL
+func (m *Manager) requiresRefresh(tr *authenticationv1.TokenRequest) bool {
 	if tr.Spec.ExpirationSeconds == nil {
 		cpy := tr.DeepCopy()
 		cpy.Status.Token = ""
@@ -144,7 +243,7 @@ func (m *Manager) requiresRefresh(ctx context.Context, tr *authenticationv1.Toke
 	}
 	now := m.clock.Now()
 	exp := tr.Status.ExpirationTimestamp.Time
-	iat := exp.Add(-1 * time.Duration(*tr.Spec.ExpirationSeconds) * time.Second)
+	iat := issuedAt(tr)
 
 	jitter := time.Duration(rand.Float64()*maxJitter.Seconds()) * time.Second
 	if now.After(iat.Add(maxTTL - jitter)) {
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/drae/templated-secret-controller/pkg/generator"
 	"github.com/stretchr/testify/assert"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,10 +22,9 @@ import (
 
 func TestTokenCachingAndExpiration(t *testing.T) {
 	type suite struct {
-		clock    *testingclock.FakeClock
-		getter   *fakeTokenGetter
-		reviewer *fakeTokenReviewer
-		mgr      *Manager
+		clock  *testingclock.FakeClock
+		getter *fakeTokenGetter
+		mgr    *Manager
 	}
 
 	type testCase struct {
@@ -73,6 +73,31 @@ func TestTokenCachingAndExpiration(t *testing.T) {
 				assert.Equal(t, tr.Status.Token, "foo", "unexpected token")
 			},
 		},
+		{
+			name: "tokens for other audiences are cached separately",
+			exp:  time.Hour,
+			f: func(t *testing.T, s *suite) {
+				tr := getTokenRequest()
+				tr.Spec.Audiences = []string{"vault"}
+
+				_, err := s.mgr.GetServiceAccountToken(context.Background(), "a", "b", tr)
+				assert.NoErrorf(t, err, "unexpected error getting token")
+				assert.Equal(t, s.getter.count, 2, "expected token to be requested for new audience, call count was %d", s.getter.count)
+
+				_, err = s.mgr.GetServiceAccountToken(context.Background(), "a", "b", tr)
+				assert.NoErrorf(t, err, "unexpected error getting token")
+				assert.Equal(t, s.getter.count, 2, "expected token to be served from cache, call count was %d", s.getter.count)
+			},
+		},
+		{
+			name: "refresh requested by context bypasses the cache",
+			exp:  time.Hour,
+			f: func(t *testing.T, s *suite) {
+				_, err := s.mgr.GetServiceAccountToken(generator.WithRefresh(context.Background()), "a", "b", getTokenRequest())
+				assert.NoErrorf(t, err, "unexpected error getting token")
+				assert.Equal(t, s.getter.count, 2, "expected token to be requested again, call count was %d", s.getter.count)
+			},
+		},
 	}
 
 	for _, c := range testCases {
@@ -82,7 +107,7 @@ func TestTokenCachingAndExpiration(t *testing.T) {
 			expSecs := int64(c.exp.Seconds())
 			s := &suite{
 				clock: clock,
-				mgr:   NewManager(nil, log),
+				mgr:   newManager(nil, log),
 				getter: &fakeTokenGetter{
 					request: &authenticationv1.TokenRequest{
 						Spec: authenticationv1.TokenRequestSpec{
@@ -94,19 +119,9 @@ func TestTokenCachingAndExpiration(t *testing.T) {
 						},
 					},
 				},
-				reviewer: &fakeTokenReviewer{
-					review: &authenticationv1.TokenReview{
-						Spec: authenticationv1.TokenReviewSpec{
-							Token: "foo",
-						},
-						Status: authenticationv1.TokenReviewStatus{
-							Authenticated: true,
-						},
-					},
-				},
 			}
 			s.mgr.getToken = s.getter.getToken
-			s.mgr.reviewToken = s.reviewer.reviewToken
+			s.mgr.reviewToken = failingTokenReviewer
 			s.mgr.clock = s.clock
 
 			_, err := s.mgr.GetServiceAccountToken(context.Background(), "a", "b", getTokenRequest())
@@ -127,7 +142,6 @@ func TestRequiresRefresh(t *testing.T) {
 
 	type testCase struct {
 		now, exp      time.Time
-		authenticated bool
 		expectRefresh bool
 	}
 
@@ -135,31 +149,21 @@ func TestRequiresRefresh(t *testing.T) {
 		{
 			now:           start.Add(1 * time.Minute),
 			exp:           start.Add(maxTTL),
-			authenticated: true,
 			expectRefresh: false,
 		},
 		{
 			now:           start.Add(59 * time.Minute),
 			exp:           start.Add(maxTTL),
-			authenticated: true,
 			expectRefresh: false,
 		},
 		{
 			now:           start.Add(61 * time.Minute),
 			exp:           start.Add(maxTTL),
-			authenticated: true,
 			expectRefresh: true,
 		},
 		{
 			now:           start.Add(3 * time.Hour),
 			exp:           start.Add(maxTTL),
-			authenticated: true,
-			expectRefresh: true,
-		},
-		{
-			now:           start.Add(1 * time.Minute),
-			exp:           start.Add(maxTTL),
-			authenticated: false,
 			expectRefresh: true,
 		},
 	}
@@ -177,22 +181,11 @@ func TestRequiresRefresh(t *testing.T) {
 					ExpirationTimestamp: metav1.Time{Time: c.exp},
 				},
 			}
-			reviewer := &fakeTokenReviewer{
-				review: &authenticationv1.TokenReview{
-					Spec: authenticationv1.TokenReviewSpec{
-						Token: "foo",
-					},
-					Status: authenticationv1.TokenReviewStatus{
-						Authenticated: c.authenticated,
-					},
-				},
-			}
-
-			mgr := NewManager(nil, log)
+			mgr := newManager(nil, log)
 			mgr.clock = clock
-			mgr.reviewToken = reviewer.reviewToken
+			mgr.reviewToken = failingTokenReviewer
 
-			rr := mgr.requiresRefresh(context.Background(), tr)
+			rr := mgr.requiresRefresh(tr)
 			assert.Equal(t, rr, c.expectRefresh, "unexpected requiresRefresh result, got: %v, want: %v - %s", rr, c.expectRefresh, c)
 		})
 	}
@@ -222,14 +215,14 @@ func TestCleanup(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			log := logf.Log.WithName("sa")
 			clock := testingclock.NewFakeClock(time.Time{}.Add(24 * time.Hour))
-			mgr := NewManager(nil, log)
+			mgr := newManager(nil, log)
 			mgr.clock = clock
 
-			mgr.set("key", &authenticationv1.TokenRequest{
+			mgr.set("key", &cachedToken{token: &authenticationv1.TokenRequest{
 				Status: authenticationv1.TokenRequestStatus{
 					ExpirationTimestamp: metav1.Time{Time: mgr.clock.Now().Add(c.relativeExp)},
 				},
-			})
+			}})
 			mgr.cleanup()
 
 			assert.Equal(t, len(mgr.cache), c.expectedCacheSize, "unexpected number of cache entries after cleanup, got: %d, want: %d", len(mgr.cache), c.expectedCacheSize)
@@ -237,6 +230,119 @@ func TestCleanup(t *testing.T) {
 	}
 }
 
+func TestBackgroundRefresh(t *testing.T) {
+	log := logf.Log.WithName("sa")
+	clock := testingclock.NewFakeClock(time.Time{}.Add(30 * 24 * time.Hour))
+	expSecs := int64(time.Hour.Seconds())
+	getter := &fakeTokenGetter{
+		request: &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{
+				ExpirationSeconds: &expSecs,
+			},
+			Status: authenticationv1.TokenRequestStatus{
+				Token:               "foo",
+				ExpirationTimestamp: metav1.Time{Time: clock.Now().Add(time.Hour)},
+			},
+		},
+	}
+
+	mgr := newManager(nil, log)
+	mgr.getToken = getter.getToken
+	mgr.reviewToken = failingTokenReviewer
+	mgr.clock = clock
+
+	_, err := mgr.GetServiceAccountToken(context.Background(), "a", "b", getTokenRequest())
+	assert.NoErrorf(t, err, "unexpected error getting token")
+	other := getTokenRequest()
+	other.Spec.Audiences = []string{"vault"}
+	_, err = mgr.GetServiceAccountToken(context.Background(), "a", "b", other)
+	assert.NoErrorf(t, err, "unexpected error getting token")
+	assert.Equal(t, 2, getter.count)
+
+	// Only tokens used since they were issued are refreshed once they are due.
+	mgr.refresh()
+	assert.Equal(t, 2, getter.count, "expected tokens not due to not be refreshed")
+
+	clock.Step(31 * time.Minute)
+	_, err = mgr.GetServiceAccountToken(context.Background(), "a", "b", getTokenRequest())
+	assert.NoErrorf(t, err, "unexpected error getting token")
+	assert.Equal(t, 3, getter.count, "expected token due to be refreshed on lookup")
+
+	clock.Step(time.Minute)
+	getter.request = getter.request.DeepCopy()
+	getter.request.Status.Token = "bar"
+	getter.request.Status.ExpirationTimestamp = metav1.Time{Time: clock.Now().Add(time.Hour)}
+	getter.count = 0
+
+	mgr.refresh()
+	assert.Equal(t, 1, getter.count, "expected only the token used since it was issued to be refreshed")
+
+	// Refreshed tokens are served from the cache without a TokenReview.
+	tr, err := mgr.GetServiceAccountToken(context.Background(), "a", "b", getTokenRequest())
+	assert.NoErrorf(t, err, "unexpected error getting token")
+	assert.Equal(t, "bar", tr.Status.Token)
+	assert.Equal(t, 1, getter.count)
+}
+
+func TestRejectedToken(t *testing.T) {
+	testCases := []struct {
+		name          string
+		authenticated bool
+		err           error
+		expectEvicted bool
+	}{
+		{name: "token still authenticated", authenticated: true},
+		{name: "token no longer authenticated", authenticated: false, expectEvicted: true},
+		{name: "review fails", err: fmt.Errorf("err"), expectEvicted: true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			log := logf.Log.WithName("sa")
+			clock := testingclock.NewFakeClock(time.Time{}.Add(30 * 24 * time.Hour))
+			expSecs := int64(time.Hour.Seconds())
+			getter := &fakeTokenGetter{
+				request: &authenticationv1.TokenRequest{
+					Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expSecs},
+					Status: authenticationv1.TokenRequestStatus{
+						Token:               "foo",
+						ExpirationTimestamp: metav1.Time{Time: clock.Now().Add(time.Hour)},
+					},
+				},
+			}
+			reviewer := &fakeTokenReviewer{
+				review: &authenticationv1.TokenReview{Status: authenticationv1.TokenReviewStatus{Authenticated: c.authenticated}},
+				err:    c.err,
+			}
+
+			mgr := newManager(nil, log)
+			mgr.getToken = getter.getToken
+			mgr.reviewToken = reviewer.reviewToken
+			mgr.clock = clock
+
+			_, err := mgr.GetServiceAccountToken(context.Background(), "a", "b", getTokenRequest())
+			assert.NoErrorf(t, err, "unexpected error getting token")
+			assert.Equal(t, 0, reviewer.count, "expected lookups not to review tokens")
+
+			mgr.RejectedToken(context.Background(), "a", "b", getTokenRequest())
+			assert.Equal(t, 1, reviewer.count)
+
+			_, err = mgr.GetServiceAccountToken(context.Background(), "a", "b", getTokenRequest())
+			assert.NoErrorf(t, err, "unexpected error getting token")
+			if c.expectEvicted {
+				assert.Equal(t, 2, getter.count, "expected rejected token to be requested again")
+			} else {
+				assert.Equal(t, 1, getter.count, "expected authenticated token to be served from cache")
+			}
+		})
+	}
+}
+
+// failingTokenReviewer fails tests reviewing tokens, as tokens are only reviewed when rejected by the API server.
+func failingTokenReviewer(_ context.Context, _ *authenticationv1.TokenReview) (*authenticationv1.TokenReview, error) {
+	panic("unexpected TokenReview")
+}
+
 type fakeTokenGetter struct {
 	count   int
 	request *authenticationv1.TokenRequest
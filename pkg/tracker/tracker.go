@@ -9,6 +9,7 @@ package tracker
 import (
 	"sync"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -18,12 +19,23 @@ import (
 type Tracker struct {
 	// Holds a set of resources(tracking) to set of resources(tracked)
 	tracker map[types.NamespacedName]map[types.NamespacedName]struct{}
-	mu      sync.RWMutex
+	// Holds a set of resources(tracking) to the label selectors of resources(tracked) they are interested in
+	selectors map[types.NamespacedName][]trackedSelector
+	mu        sync.RWMutex
+}
+
+// trackedSelector matches resources within a namespace by their labels
+type trackedSelector struct {
+	namespace string
+	selector  labels.Selector
 }
 
 // NewTracker creates a new Tracker
 func NewTracker() *Tracker {
-	return &Tracker{tracker: map[types.NamespacedName]map[types.NamespacedName]struct{}{}}
+	return &Tracker{
+		tracker:   map[types.NamespacedName]map[types.NamespacedName]struct{}{},
+		selectors: map[types.NamespacedName][]trackedSelector{},
+	}
 }
 
 // Track records that the tracking object is interested in all tracked objects
@@ -40,11 +52,20 @@ func (s *Tracker) Track(tracking types.NamespacedName, tracked ...types.Namespac
 	}
 }
 
+// TrackSelector records that the tracking object is interested in all objects in namespace matching selector,
+// including objects that do not exist yet
+func (s *Tracker) TrackSelector(tracking types.NamespacedName, namespace string, selector labels.Selector) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.selectors[tracking] = append(s.selectors[tracking], trackedSelector{namespace: namespace, selector: selector})
+}
+
 // UntrackAll untracks all tracking objects. This method is idempotent
 func (s *Tracker) UntrackAll(tracking types.NamespacedName) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.tracker, tracking)
+	delete(s.selectors, tracking)
 }
 
 // GetTracking returns all tracking objects for a given tracked object
@@ -59,3 +80,19 @@ func (s *Tracker) GetTracking(tracked types.NamespacedName) []types.NamespacedNa
 	}
 	return trackingList
 }
+
+// GetTrackingByLabels returns all tracking objects with a selector matching an object in namespace with the given labels
+func (s *Tracker) GetTrackingByLabels(namespace string, objLabels map[string]string) []types.NamespacedName {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	trackingList := []types.NamespacedName{}
+	for tracking, selectors := range s.selectors {
+		for _, selector := range selectors {
+			if selector.namespace == namespace && selector.selector.Matches(labels.Set(objLabels)) {
+				trackingList = append(trackingList, tracking)
+				break
+			}
+		}
+	}
+	return trackingList
+}
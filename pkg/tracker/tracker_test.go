@@ -8,6 +8,7 @@ import (
 
 	"github.com/drae/templated-secret-controller/pkg/tracker"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -40,4 +41,26 @@ func Test_Tracker(t *testing.T) {
 		tracker.UntrackAll(tracking2)
 		assert.Len(t, tracker.GetTracking(tracked1), 0, "should be zero tracking")
 	})
+
+	t.Run("Test tracker with selectors", func(t *testing.T) {
+		tracking1 := types.NamespacedName{Namespace: "ns1", Name: "tracking"}
+		tracking2 := types.NamespacedName{Namespace: "ns1", Name: "tracking2"}
+		tracked := types.NamespacedName{Namespace: "ns1", Name: "tracked"}
+
+		tracker := tracker.NewTracker()
+
+		tracker.Track(tracking1, tracked)
+		tracker.TrackSelector(tracking1, "ns1", labels.SelectorFromSet(labels.Set{"app": "kafka"}))
+		tracker.TrackSelector(tracking2, "ns1", labels.SelectorFromSet(labels.Set{"team": "x"}))
+
+		assert.ElementsMatch(t, tracker.GetTrackingByLabels("ns1", map[string]string{"app": "kafka", "tier": "backend"}), []types.NamespacedName{tracking1}, "did not contain tracking resource")
+		assert.ElementsMatch(t, tracker.GetTrackingByLabels("ns1", map[string]string{"team": "x"}), []types.NamespacedName{tracking2}, "did not contain tracking resource")
+		assert.Len(t, tracker.GetTrackingByLabels("ns2", map[string]string{"app": "kafka"}), 0, "should not match other namespaces")
+		assert.Len(t, tracker.GetTrackingByLabels("ns1", nil), 0, "should be zero tracking")
+
+		tracker.UntrackAll(tracking1)
+		assert.Len(t, tracker.GetTrackingByLabels("ns1", map[string]string{"app": "kafka"}), 0, "should be zero tracking")
+		assert.Len(t, tracker.GetTracking(tracked), 0, "should be zero tracking")
+		assert.ElementsMatch(t, tracker.GetTrackingByLabels("ns1", map[string]string{"team": "x"}), []types.NamespacedName{tracking2}, "did not contain tracking resource")
+	})
 }
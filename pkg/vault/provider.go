@@ -0,0 +1,330 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package vault resolves input resources from secrets stored in a HashiCorp Vault KV secrets engine.
+// SecretTemplates log in to Vault with the Kubernetes auth method, using a token of their Service Account.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+)
+
+const (
+	defaultAuthMount = "kubernetes"
+	defaultKVMount   = "secret"
+	defaultKVVersion = 2
+
+	// Vault tokens are renewed by logging in again once this fraction of their lease has passed.
+	tokenRenewalFraction = 0.8
+)
+
+// Config configures how Vault is reached.
+type Config struct {
+	// Address of the Vault server, e.g. https://vault.vault.svc:8200.
+	Address string
+	// Mount path of the Kubernetes auth method. Defaults to "kubernetes".
+	AuthMount string
+	// Path of a PEM encoded CA bundle used to verify the Vault server certificate.
+	// The system roots are used if not set.
+	CACertFile string
+	// Vault Enterprise namespace. Optional.
+	Namespace string
+	// Lifetime and audiences of the Service Account tokens used to log in, unless set by the SecretTemplate's
+	// Service Account. The audiences must match the audience of the Vault role, if it has one.
+	Tokens generator.TokenSettings
+}
+
+// Provider is an InputProvider reading input resources from Vault. Provider is thread-safe.
+type Provider struct {
+	config       Config
+	httpClient   *http.Client
+	tokenManager generator.TokenManager
+
+	// Holds Vault tokens by role and Service Account
+	tokens map[string]vaultToken
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+type vaultToken struct {
+	token   string
+	renewAt time.Time
+}
+
+var _ generator.InputProvider = &Provider{}
+
+// NewProvider creates a new Provider. Service Account tokens are requested from tokenManager.
+func NewProvider(config Config, tokenManager generator.TokenManager) (*Provider, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("vault address must not be empty")
+	}
+	if config.AuthMount == "" {
+		config.AuthMount = defaultAuthMount
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CACertFile != "" {
+		caData, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading vault CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in vault CA certificate %s", config.CACertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &Provider{
+		config:       config,
+		httpClient:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		tokenManager: tokenManager,
+		tokens:       map[string]vaultToken{},
+		now:          time.Now,
+	}, nil
+}
+
+// Resolve reads the secret an input resource refers to and returns its fields.
+func (p *Provider) Resolve(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, input tsv1alpha1.InputResource) (generator.ProvidedInput, error) {
+	source := input.Vault
+	if source == nil {
+		return generator.ProvidedInput{}, fmt.Errorf("input resource %s does not refer to vault", input.Name)
+	}
+	if secretTemplate.Spec.GetServiceAccountName() == "" {
+		return generator.ProvidedInput{}, fmt.Errorf("unable to read from vault without a specified serviceaccount")
+	}
+	if source.Role == "" || source.Path == "" {
+		return generator.ProvidedInput{}, fmt.Errorf("vault role and path must not be empty")
+	}
+
+	secretPath, err := kvPath(*source)
+	if err != nil {
+		return generator.ProvidedInput{}, err
+	}
+
+	fields, err := p.read(ctx, secretTemplate, source.Role, secretPath, kvVersion(source.KVVersion))
+	if err != nil {
+		return generator.ProvidedInput{}, err
+	}
+	return generator.ProvidedInput{Values: fields}, nil
+}
+
+// read returns the fields of the secret at secretPath.
+func (p *Provider) read(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, role, secretPath string, version int) (map[string]interface{}, error) {
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	status, err := p.withToken(ctx, secretTemplate, role, func(token string) (int, error) {
+		status, err := p.do(ctx, http.MethodGet, secretPath, token, nil, &secret)
+		if err != nil {
+			return status, fmt.Errorf("reading vault secret %s: %w", secretPath, err)
+		}
+		return status, nil
+	})
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("reading vault secret %s: %w", secretPath, generator.ErrInputNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 2 {
+		// Version 2 of the KV secrets engine nests the fields alongside metadata. Deleted and destroyed versions have no fields.
+		versioned, ok := secret.Data["data"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("reading vault secret %s: %w", secretPath, generator.ErrInputNotFound)
+		}
+		return versioned, nil
+	}
+	return secret.Data, nil
+}
+
+// withToken calls fn with a Vault token of the SecretTemplate's Service Account. If the token is rejected
+// it may have been revoked, so fn is called once more after logging in again.
+func (p *Provider) withToken(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, role string, fn func(token string) (int, error)) (int, error) {
+	serviceAccount := secretTemplate.Spec.GetServiceAccount()
+	tokenKey := fmt.Sprintf("%q/%q/%q", role, secretTemplate.Namespace, serviceAccount.Name)
+
+	token, err := p.token(ctx, tokenKey, role, secretTemplate.Namespace, *serviceAccount)
+	if err != nil {
+		return 0, err
+	}
+
+	status, err := fn(token)
+	if status == http.StatusForbidden {
+		p.forget(tokenKey)
+		if token, err = p.token(ctx, tokenKey, role, secretTemplate.Namespace, *serviceAccount); err != nil {
+			return 0, err
+		}
+		status, err = fn(token)
+	}
+	return status, err
+}
+
+// token returns a Vault token for the Service Account, logging in if there is no valid token cached.
+func (p *Provider) token(ctx context.Context, key, role, namespace string, serviceAccount tsv1alpha1.SecretTemplateServiceAccount) (string, error) {
+	p.mu.Lock()
+	cached, found := p.tokens[key]
+	p.mu.Unlock()
+
+	if found && p.now().Before(cached.renewAt) {
+		return cached.token, nil
+	}
+
+	tokenRequest, err := p.tokenManager.GetServiceAccountToken(ctx, namespace, serviceAccount.Name, p.config.Tokens.TokenRequest(serviceAccount))
+	if err != nil {
+		return "", fmt.Errorf("requesting service account token: %w", err)
+	}
+
+	var login struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	loginPath := "/v1/auth/" + strings.Trim(p.config.AuthMount, "/") + "/login"
+	if _, err := p.do(ctx, http.MethodPut, loginPath, "", map[string]string{"role": role, "jwt": tokenRequest.Status.Token}, &login); err != nil {
+		return "", fmt.Errorf("logging in to vault with role %s: %w", role, err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("logging in to vault with role %s: no client token returned", role)
+	}
+
+	lease := time.Duration(float64(time.Duration(login.Auth.LeaseDuration)*time.Second) * tokenRenewalFraction)
+	if lease <= 0 {
+		// Tokens without a lease do not expire, but are still renewed periodically in case they are revoked.
+		lease = time.Hour
+	}
+	p.mu.Lock()
+	p.tokens[key] = vaultToken{token: login.Auth.ClientToken, renewAt: p.now().Add(lease)}
+	p.mu.Unlock()
+
+	return login.Auth.ClientToken, nil
+}
+
+func (p *Provider) forget(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tokens, key)
+}
+
+// do sends a request to Vault and decodes the response into out, unless out is nil. It returns the status code of the response.
+func (p *Provider) do(ctx context.Context, method, path, token string, in, out interface{}) (int, error) {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(p.config.Address, "/")+path, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&vaultErr)
+		if len(vaultErr.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("vault responded with %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, ", "))
+		}
+		return resp.StatusCode, fmt.Errorf("vault responded with %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	// Keep numbers as they are stored, e.g. large ids should not be formatted as floats.
+	decoder.UseNumber()
+	return resp.StatusCode, decoder.Decode(out)
+}
+
+func kvVersion(version int) int {
+	if version == 0 {
+		return defaultKVVersion
+	}
+	return version
+}
+
+// kvPath returns the API path of the secret an input resource refers to.
+func kvPath(source tsv1alpha1.VaultInputSource) (string, error) {
+	path, err := kvSecretPath(source.Mount, source.Path, source.KVVersion)
+	if err != nil {
+		return "", err
+	}
+	if source.Version != 0 {
+		if kvVersion(source.KVVersion) != 2 {
+			return "", fmt.Errorf("version is only supported by version 2 of the KV secrets engine")
+		}
+		path += "?" + url.Values{"version": []string{strconv.Itoa(source.Version)}}.Encode()
+	}
+	return path, nil
+}
+
+// kvSecretPath returns the API path used to read and write the latest version of a secret.
+func kvSecretPath(mount, path string, version int) (string, error) {
+	if mount == "" {
+		mount = defaultKVMount
+	}
+	mount, err := escapePath(mount)
+	if err != nil {
+		return "", fmt.Errorf("mount: %w", err)
+	}
+	secretPath, err := escapePath(path)
+	if err != nil {
+		return "", fmt.Errorf("path: %w", err)
+	}
+
+	switch kvVersion(version) {
+	case 1:
+		return "/v1/" + mount + "/" + secretPath, nil
+	case 2:
+		return "/v1/" + mount + "/data/" + secretPath, nil
+	default:
+		return "", fmt.Errorf("unsupported KV secrets engine version %d", version)
+	}
+}
+
+// escapePath escapes every segment of a Vault path. Relative segments are not allowed.
+func escapePath(path string) (string, error) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("%q is not a valid vault path", path)
+		}
+		segments = append(segments, url.PathEscape(segment))
+	}
+	return strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package vault_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+	"github.com/drae/templated-secret-controller/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Provider_Resolve(t *testing.T) {
+	fake := newFakeVault()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, err := vault.NewProvider(vault.Config{Address: server.URL}, fakeTokenManager{})
+	require.NoError(t, err)
+
+	type test struct {
+		name           string
+		source         tsv1alpha1.VaultInputSource
+		expectedValues map[string]interface{}
+		expectedError  string
+	}
+
+	tests := []test{
+		{
+			name:           "latest version of a KV version 2 secret",
+			source:         tsv1alpha1.VaultInputSource{Role: "app", Path: "db"},
+			expectedValues: map[string]interface{}{"password": "rotated", "port": json.Number("5432")},
+		},
+		{
+			name:           "specific version of a KV version 2 secret",
+			source:         tsv1alpha1.VaultInputSource{Role: "app", Path: "/db/", Version: 1},
+			expectedValues: map[string]interface{}{"password": "initial", "port": json.Number("5432")},
+		},
+		{
+			name:           "KV version 1 secret",
+			source:         tsv1alpha1.VaultInputSource{Role: "app", Mount: "kv", Path: "team/db", KVVersion: 1},
+			expectedValues: map[string]interface{}{"password": "v1"},
+		},
+		{
+			name:          "missing secret",
+			source:        tsv1alpha1.VaultInputSource{Role: "app", Path: "missing"},
+			expectedError: "reading vault secret /v1/secret/data/missing: input not found",
+		},
+		{
+			name:          "deleted version of a secret",
+			source:        tsv1alpha1.VaultInputSource{Role: "app", Path: "db", Version: 3},
+			expectedError: "reading vault secret /v1/secret/data/db?version=3: input not found",
+		},
+		{
+			name:          "role not bound to the service account",
+			source:        tsv1alpha1.VaultInputSource{Role: "admin", Path: "db"},
+			expectedError: "logging in to vault with role admin: vault responded with 403: permission denied",
+		},
+		{
+			name:          "relative path",
+			source:        tsv1alpha1.VaultInputSource{Role: "app", Path: "../sys/seal"},
+			expectedError: `path: "../sys/seal" is not a valid vault path`,
+		},
+		{
+			name:          "version of a KV version 1 secret",
+			source:        tsv1alpha1.VaultInputSource{Role: "app", Path: "db", KVVersion: 1, Version: 1},
+			expectedError: "version is only supported by version 2 of the KV secrets engine",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			provided, err := provider.Resolve(context.Background(), secretTemplate("reader"), tsv1alpha1.InputResource{Name: "db", Vault: &tc.source})
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValues, provided.Values)
+		})
+	}
+
+	t.Run("missing secrets are reported as not found", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), tsv1alpha1.InputResource{Name: "db", Vault: &tsv1alpha1.VaultInputSource{Role: "app", Path: "missing"}})
+		assert.ErrorIs(t, err, generator.ErrInputNotFound)
+	})
+
+	t.Run("service account is required", func(t *testing.T) {
+		_, err := provider.Resolve(context.Background(), secretTemplate(""), tsv1alpha1.InputResource{Name: "db", Vault: &tsv1alpha1.VaultInputSource{Role: "app", Path: "db"}})
+		assert.EqualError(t, err, "unable to read from vault without a specified serviceaccount")
+	})
+}
+
+func Test_Provider_Tokens(t *testing.T) {
+	fake := newFakeVault()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	var requests []authv1.TokenRequestSpec
+	provider, err := vault.NewProvider(vault.Config{
+		Address:   server.URL,
+		AuthMount: "k8s-cluster",
+		Tokens:    generator.TokenSettings{Expiration: 2 * time.Hour, Audiences: []string{"vault"}},
+	}, fakeTokenManager{requests: &requests})
+	require.NoError(t, err)
+
+	input := tsv1alpha1.InputResource{Name: "db", Vault: &tsv1alpha1.VaultInputSource{Role: "app", Path: "db"}}
+
+	for i := 0; i < 3; i++ {
+		_, err := provider.Resolve(context.Background(), secretTemplate("reader"), input)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, fake.logins(), "expected the vault token to be reused")
+
+	expiration := int64(7200)
+	assert.Equal(t, []authv1.TokenRequestSpec{{Audiences: []string{"vault"}, ExpirationSeconds: &expiration}}, requests)
+
+	// Tokens are not shared between Service Accounts.
+	_, err = provider.Resolve(context.Background(), secretTemplate("other-reader"), input)
+	require.EqualError(t, err, "logging in to vault with role app: vault responded with 403: permission denied")
+
+	// Revoked tokens are replaced by logging in again.
+	fake.revokeTokens()
+	_, err = provider.Resolve(context.Background(), secretTemplate("reader"), input)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fake.logins())
+}
+
+func secretTemplate(serviceAccount string) *tsv1alpha1.SecretTemplate {
+	return &tsv1alpha1.SecretTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "secretTemplate", Namespace: "test"},
+		Spec:       tsv1alpha1.SecretTemplateSpec{ServiceAccountName: serviceAccount},
+	}
+}
+
+type fakeTokenManager struct {
+	// Records the specs of token requests, if set
+	requests *[]authv1.TokenRequestSpec
+}
+
+func (m fakeTokenManager) GetServiceAccountToken(_ context.Context, namespace, name string, tr *authv1.TokenRequest) (*authv1.TokenRequest, error) {
+	if m.requests != nil {
+		*m.requests = append(*m.requests, tr.Spec)
+	}
+	tr.Status.Token = fmt.Sprintf("jwt-%s-%s", namespace, name)
+	return tr, nil
+}
+
+// fakeVault implements the parts of the Vault API used by the provider. Only the Service Account "test/reader"
+// may log in with the role "app".
+type fakeVault struct {
+	mu          sync.Mutex
+	loginCount  int
+	validTokens map[string]bool
+	// Secrets written by push targets, by API path
+	written map[string]map[string]interface{}
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{validTokens: map[string]bool{}, written: map[string]map[string]interface{}{}}
+}
+
+func (f *fakeVault) logins() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.loginCount
+}
+
+func (f *fakeVault) revokeTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.validTokens = map[string]bool{}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/auth/") && strings.HasSuffix(r.URL.Path, "/login") {
+		var login struct {
+			Role string `json:"role"`
+			JWT  string `json:"jwt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.Role != "app" || login.JWT != "jwt-test-reader" {
+			respond(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		f.loginCount++
+		token := fmt.Sprintf("hvs.%d", f.loginCount)
+		f.validTokens[token] = true
+		respond(w, http.StatusOK, map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600}})
+		return
+	}
+
+	if !f.validTokens[r.Header.Get("X-Vault-Token")] {
+		respond(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respond(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{err.Error()}})
+			return
+		}
+		f.written[r.URL.Path] = body
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if written, found := f.written[r.URL.Path]; found {
+		respond(w, http.StatusOK, map[string]interface{}{"data": written})
+		return
+	}
+
+	// Version 3 of the secret has been deleted.
+	versions := map[string]map[string]interface{}{
+		"1": {"password": "initial", "port": 5432},
+		"2": {"password": "rotated", "port": 5432},
+		"3": nil,
+	}
+
+	switch r.URL.Path {
+	case "/v1/secret/data/db":
+		version := r.URL.Query().Get("version")
+		if version == "" {
+			version = "2"
+		}
+		status := http.StatusOK
+		if versions[version] == nil {
+			status = http.StatusNotFound
+		}
+		respond(w, status, map[string]interface{}{"data": map[string]interface{}{
+			"data":     versions[version],
+			"metadata": map[string]interface{}{"version": version},
+		}})
+	case "/v1/kv/team/db":
+		respond(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"password": "v1"}})
+	default:
+		respond(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/generator"
+)
+
+var _ generator.PushSink = &Provider{}
+
+// Read returns the fields of the secret a push target refers to.
+func (p *Provider) Read(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget) (map[string]string, bool, error) {
+	secretPath, err := pushPath(secretTemplate, target)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fields, err := p.read(ctx, secretTemplate, target.Vault.Role, secretPath, kvVersion(target.Vault.KVVersion))
+	if err != nil {
+		if errors.Is(err, generator.ErrInputNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	data := make(map[string]string, len(fields))
+	for key, value := range fields {
+		data[key] = fmt.Sprint(value)
+	}
+	return data, true, nil
+}
+
+// Write replaces the fields of the secret a push target refers to. With version 2 of the KV secrets engine
+// a new version of the secret is created.
+func (p *Provider) Write(ctx context.Context, secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget, data map[string]string) error {
+	secretPath, err := pushPath(secretTemplate, target)
+	if err != nil {
+		return err
+	}
+
+	var body interface{} = data
+	if kvVersion(target.Vault.KVVersion) == 2 {
+		body = map[string]interface{}{"data": data}
+	}
+
+	_, err = p.withToken(ctx, secretTemplate, target.Vault.Role, func(token string) (int, error) {
+		status, err := p.do(ctx, http.MethodPost, secretPath, token, body, nil)
+		if err != nil {
+			return status, fmt.Errorf("writing vault secret %s: %w", secretPath, err)
+		}
+		return status, nil
+	})
+	return err
+}
+
+func pushPath(secretTemplate *tsv1alpha1.SecretTemplate, target tsv1alpha1.PushTarget) (string, error) {
+	if target.Vault == nil {
+		return "", fmt.Errorf("push target %s does not refer to vault", target.Name)
+	}
+	if secretTemplate.Spec.GetServiceAccountName() == "" {
+		return "", fmt.Errorf("unable to push to vault without a specified serviceaccount")
+	}
+	if target.Vault.Role == "" || target.Vault.Path == "" {
+		return "", fmt.Errorf("vault role and path must not be empty")
+	}
+	return kvSecretPath(target.Vault.Mount, target.Vault.Path, target.Vault.KVVersion)
+}
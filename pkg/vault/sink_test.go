@@ -0,0 +1,61 @@
+// Copyright 2024 The Carvel Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package vault_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	tsv1alpha1 "github.com/drae/templated-secret-controller/pkg/apis/templatedsecret/v1alpha1"
+	"github.com/drae/templated-secret-controller/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Provider_Push(t *testing.T) {
+	fake := newFakeVault()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, err := vault.NewProvider(vault.Config{Address: server.URL}, fakeTokenManager{})
+	require.NoError(t, err)
+
+	data := map[string]string{"username": "admin", "password": "p@ss"}
+
+	tests := []struct {
+		name   string
+		target tsv1alpha1.VaultPushTarget
+	}{
+		{name: "KV version 2 secret", target: tsv1alpha1.VaultPushTarget{Role: "app", Path: "pushed"}},
+		{name: "KV version 1 secret", target: tsv1alpha1.VaultPushTarget{Role: "app", Mount: "kv", Path: "team/pushed", KVVersion: 1}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pushTarget := tsv1alpha1.PushTarget{Name: "vault", Vault: &tc.target}
+
+			_, found, err := provider.Read(context.Background(), secretTemplate("reader"), pushTarget)
+			require.NoError(t, err)
+			assert.False(t, found)
+
+			require.NoError(t, provider.Write(context.Background(), secretTemplate("reader"), pushTarget, data))
+
+			current, found, err := provider.Read(context.Background(), secretTemplate("reader"), pushTarget)
+			require.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, data, current)
+		})
+	}
+
+	t.Run("role not bound to the service account", func(t *testing.T) {
+		err := provider.Write(context.Background(), secretTemplate("other-reader"), tsv1alpha1.PushTarget{Name: "vault", Vault: &tsv1alpha1.VaultPushTarget{Role: "app", Path: "pushed"}}, data)
+		assert.EqualError(t, err, "logging in to vault with role app: vault responded with 403: permission denied")
+	})
+
+	t.Run("service account is required", func(t *testing.T) {
+		err := provider.Write(context.Background(), secretTemplate(""), tsv1alpha1.PushTarget{Name: "vault", Vault: &tsv1alpha1.VaultPushTarget{Role: "app", Path: "pushed"}}, data)
+		assert.EqualError(t, err, "unable to push to vault without a specified serviceaccount")
+	})
+}